@@ -0,0 +1,76 @@
+package routedb
+
+import "strings"
+
+// Whether a route or stop can be used in a wheelchair, with the same
+// values as wheelchair_accessible and wheelchair_boarding in GTFS.
+const (
+	WheelchairUnknown      = 0
+	WheelchairAccessible   = 1
+	WheelchairInaccessible = 2
+)
+
+// parseWheelchair returns the accessibility given as "yes" or "no",
+// ignoring case, in a GPX extension.
+func parseWheelchair(s string) int {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "yes", "true":
+		return WheelchairAccessible
+	case "no", "false":
+		return WheelchairInaccessible
+	}
+	return WheelchairUnknown
+}
+
+// RouteWheelchair returns whether the vehicles of route i can carry a
+// wheelchair: WheelchairUnknown, WheelchairAccessible or
+// WheelchairInaccessible. It is given in the extensions of the track
+// in the route's GPX file, as described for trackExtensions, and is
+// also in the Route FlatBuffer.
+func (db *Db) RouteWheelchair(i int) (int, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return WheelchairUnknown, err
+	}
+	return t.wheelchair, nil
+}
+
+// AccessibleRoutes returns the indices of the routes whose vehicles
+// are known to carry a wheelchair.
+func (db *Db) AccessibleRoutes() []int {
+	routes := []int{}
+	for i, t := range db.routes {
+		if t.wheelchair == WheelchairAccessible {
+			routes = append(routes, i)
+		}
+	}
+	return routes
+}
+
+// accessibleAt reports whether boarding point k of t, as given by
+// boardingPoints, can be used in a wheelchair: the route's vehicles
+// must be known to carry one, and a named stop must be known to be
+// accessible too.
+func (t *track) accessibleAt(k int) bool {
+	if t.wheelchair != WheelchairAccessible {
+		return false
+	}
+	return len(t.stops) == 0 || t.stops[k].wheelchair == WheelchairAccessible
+}
+
+// NearestAccessible is like Nearest, but only considers the stops
+// which can be used in a wheelchair: those of the routes known to be
+// accessible, except for named stops which are not known to be so.
+func (db *Db) NearestAccessible(lat, lon float64) (*Stop, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	ri, k, _ := db.nearestBoardingWhere(Stop{lat, lon}, func(ri, k int) bool {
+		return db.routes[ri].accessibleAt(k)
+	})
+	if ri < 0 {
+		return nil, errNoStop
+	}
+	pt := db.routes[ri].boardingPoint(k)
+	return &Stop{Lat: pt.Lat, Lon: pt.Lon}, nil
+}
@@ -0,0 +1,81 @@
+package routedb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestAccessibility(t *testing.T) {
+	in := zipGPX(t, `<gpx><metadata><name>kg-osh-1</name></metadata>
+<wpt lat="40.5" lon="72.8"><name>A</name><type>stop</type><extensions><wheelchair>yes</wheelchair></extensions></wpt>
+<wpt lat="40.5" lon="72.81"><name>B</name><type>stop</type><extensions><wheelchair>no</wheelchair></extensions></wpt>
+<wpt lat="40.5" lon="72.82"><name>C</name><type>stop</type></wpt>
+<trk><extensions><wheelchair>Yes</wheelchair></extensions>
+<trkseg><trkpt lat="40.5" lon="72.8"/><trkpt lat="40.5" lon="72.82"/></trkseg></trk></gpx>`,
+		`<gpx><metadata><name>kg-osh-2</name></metadata>
+<trk><extensions><wheelchair>no</wheelchair></extensions>
+<trkseg><trkpt lat="40.51" lon="72.81"/><trkpt lat="40.51" lon="72.82"/></trkseg></trk></gpx>`,
+		`<gpx><metadata><name>kg-osh-3</name></metadata>
+<trk><extensions><wheelchair>true</wheelchair></extensions>
+<trkseg><trkpt lat="40.52" lon="72.81"/><trkpt lat="40.52" lon="72.82"/></trkseg></trk></gpx>`,
+		`<gpx><metadata><name>kg-osh-4</name></metadata>
+<trk><trkseg><trkpt lat="40.5" lon="72.815"/></trkseg></trk></gpx>`)
+
+	for _, opts := range []*LoadOptions{nil, {NoSpatialIndex: true}} {
+		sdb, err := LoadWithOptions(in, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range []int{WheelchairAccessible, WheelchairInaccessible, WheelchairAccessible, WheelchairUnknown} {
+			w, err := sdb.RouteWheelchair(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w != want {
+				t.Errorf("route %v wheelchair is %v, expected %v", i, w, want)
+			}
+			buf, _ := sdb.Route(i)
+			if w := route.GetRootAsRoute(buf, 0).Wheelchair(); int(w) != want {
+				t.Errorf("route %v FlatBuffer wheelchair is %v, expected %v", i, w, want)
+			}
+		}
+		if routes := sdb.AccessibleRoutes(); fmt.Sprint(routes) != "[0 2]" {
+			t.Errorf("accessible routes are %v", routes)
+		}
+		stops, _ := sdb.RouteNamedStops(0)
+		for k, want := range []int{WheelchairAccessible, WheelchairInaccessible, WheelchairUnknown} {
+			if stops[k].Wheelchair != want {
+				t.Errorf("stop %v wheelchair is %v, expected %v", k, stops[k].Wheelchair, want)
+			}
+		}
+
+		for _, tc := range []struct {
+			lat, lon float64
+			want     Stop
+		}{
+			// Stop B, route 4 and stop C are nearer, but not
+			// known to be accessible.
+			{40.5, 72.811, Stop{40.5, 72.8}},
+			{40.5, 72.806, Stop{40.5, 72.8}},
+			// Route 2 is nearer, but not accessible.
+			{40.511, 72.82, Stop{40.52, 72.82}},
+		} {
+			s, err := sdb.NearestAccessible(tc.lat, tc.lon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *s != tc.want {
+				t.Errorf("index %v: nearest accessible to %v, %v is %v, expected %v", opts == nil, tc.lat, tc.lon, *s, tc.want)
+			}
+		}
+	}
+
+	if _, err := db.NearestAccessible(40.5, 72.8); err != errNoStop {
+		t.Errorf("expected no stop in the fixture, got %v", err)
+	}
+	if _, err := db.RouteWheelchair(1); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
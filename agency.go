@@ -0,0 +1,82 @@
+package routedb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// agenciesFile is the name of the file in a routedb zip holding the
+// agency table.
+const agenciesFile = "agencies.json"
+
+// An Agency is an operator running routes, with how to contact it,
+// such as about a lost item.
+//
+// The agencies are listed in the file agencies.json in the routedb
+// zip, as a JSON array of objects with the fields below, and routes
+// refer to theirs by ID, with an <agency> element in the extensions of
+// the track in their GPX file:
+//
+//	[{"id": "oshbus", "name": "Osh Bus", "phone": "+996 3222 55555",
+//	  "url": "http://oshbus.kg"}]
+type Agency struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	URL   string `json:"url"`
+}
+
+// parseAgencies parses the agency table fn, read from r, adding its
+// agencies to those in agencies, by ID.
+func parseAgencies(fn string, r io.Reader, agencies map[string]*Agency) error {
+	var list []*Agency
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return fmt.Errorf("Failed to parse %v: %v", fn, err)
+	}
+	return addAgencies(fn, list, agencies)
+}
+
+// addAgencies adds the agencies in list, read from the file fn, to
+// those in agencies, by ID.
+func addAgencies(fn string, list []*Agency, agencies map[string]*Agency) error {
+	for _, a := range list {
+		if a.ID == "" {
+			return fmt.Errorf("In file %v agency %q has no id", fn, a.Name)
+		}
+		if agencies[a.ID] != nil {
+			return fmt.Errorf("In file %v agency id %v is repeated", fn, a.ID)
+		}
+		agencies[a.ID] = a
+	}
+	return nil
+}
+
+// resolveAgencies finds the agency referred to by each route in
+// agencies, with a warning for each which cannot be found.
+func (db *Db) resolveAgencies(agencies map[string]*Agency) {
+	for _, t := range db.routes {
+		if t.agencyID == "" {
+			continue
+		}
+		t.agency = agencies[t.agencyID]
+		if t.agency == nil {
+			db.warnings = append(db.warnings, fmt.Sprintf("Route %v refers to unknown agency %v", t.id, t.agencyID))
+		}
+	}
+}
+
+// Agency returns the agency running route i. It is an error for the
+// route to have no agency given, or one missing from the agency table.
+func (db *Db) Agency(i int) (*Agency, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	if t.agency == nil {
+		return nil, errors.New("route has no agency")
+	}
+	a := *t.agency
+	return &a, nil
+}
@@ -0,0 +1,59 @@
+package routedb
+
+import (
+	"strings"
+	"testing"
+)
+
+// agencyGPX returns a GPX file for a route run by agency.
+func agencyGPX(agency string) string {
+	return `<gpx><metadata><name>kg-osh-1</name></metadata>
+<trk><extensions><agency>` + agency + `</agency></extensions>
+<trkseg><trkpt lat="40.5" lon="72.8"/></trkseg></trk></gpx>`
+}
+
+func TestAgency(t *testing.T) {
+	// The agency table comes after the routes referring to it.
+	sdb, err := Load(zipFiles(t,
+		"0.xml", agencyGPX("oshbus"),
+		"1.xml", agencyGPX("nonesuch"),
+		"2.xml", agencyGPX(""),
+		agenciesFile, `[
+{"id": "oshbus", "name": "Osh Bus", "phone": "+996 3222 55555", "url": "http://oshbus.kg"},
+{"id": "other", "name": "Other"}
+]`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := sdb.Agency(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *a != (Agency{"oshbus", "Osh Bus", "+996 3222 55555", "http://oshbus.kg"}) {
+		t.Errorf("agency is %+v", a)
+	}
+	for _, i := range []int{1, 2} {
+		if _, err := sdb.Agency(i); err == nil {
+			t.Errorf("route %v: expected error", i)
+		}
+	}
+	if _, err := sdb.Agency(3); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if w := sdb.Warnings(); !strings.Contains(w, "unknown agency nonesuch") {
+		t.Errorf("warnings are %q", w)
+	}
+}
+
+func TestAgencyTableErrors(t *testing.T) {
+	for _, table := range []string{
+		`{"id": "oshbus"}`,
+		`[{"name": "Osh Bus"}]`,
+		`[{"id": "oshbus"}, {"id": "oshbus"}]`,
+	} {
+		if _, err := Load(zipFiles(t, "0.xml", agencyGPX("oshbus"), agenciesFile, table)); err == nil {
+			t.Errorf("expected error for agency table %v", table)
+		}
+	}
+}
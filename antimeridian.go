@@ -0,0 +1,65 @@
+package routedb
+
+import "math"
+
+// FixAntimeridian splits every segment crossing the antimeridian,
+// such as one from longitude 179.9 to -179.9, which naive renderers
+// draw the long way round the globe. Two boundary points are inserted
+// into such a segment, at longitudes 180 and -180 and at the latitude
+// where it crosses, so that each part stays within one hemisphere.
+// The two boundary points are the same place, so lengths and
+// distances are unchanged, and a renderer can tell where to lift the
+// pen by the jump in longitude between them.
+//
+// Like all the methods changing the database, it must not be called
+// concurrently with queries.
+func (db *Db) FixAntimeridian() {
+	changed := false
+	for _, t := range db.routes {
+		if fixAntimeridian(t) {
+			t.update()
+			changed = true
+		}
+	}
+	if changed {
+		db.RecomputeBounds()
+	}
+}
+
+// fixAntimeridian splits the segments of t crossing the antimeridian,
+// reporting whether there were any.
+func fixAntimeridian(t *track) bool {
+	var pts []Stop
+	var ele []float64
+	changed := false
+	for j, pt := range t.pts {
+		if j > 0 {
+			// A segment with an end on the antimeridian
+			// itself, like the one between two boundary
+			// points, is already within a hemisphere.
+			a := t.pts[j-1]
+			if math.Abs(pt.Lon-a.Lon) > 180 && math.Abs(a.Lon) != 180 && math.Abs(pt.Lon) != 180 {
+				// Unwrap b's longitude to measure how far
+				// along the segment the crossing is.
+				side := math.Copysign(180, a.Lon)
+				blon := pt.Lon + 2*side
+				f := (side - a.Lon) / (blon - a.Lon)
+				lat := a.Lat + f*(pt.Lat-a.Lat)
+				pts = append(pts, Stop{lat, side}, Stop{lat, -side})
+				if t.ele != nil {
+					e := t.ele[j-1] + f*(t.ele[j]-t.ele[j-1])
+					ele = append(ele, e, e)
+				}
+				changed = true
+			}
+		}
+		pts = append(pts, pt)
+		if t.ele != nil {
+			ele = append(ele, t.ele[j])
+		}
+	}
+	if changed {
+		t.pts, t.ele = pts, ele
+	}
+	return changed
+}
@@ -0,0 +1,47 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFixAntimeridian(t *testing.T) {
+	db := loadTestdata(t, "testdata/antimeridian.zip")
+	before, _ := db.RouteLength(0)
+	db.FixAntimeridian()
+
+	pts := db.routes[0].pts
+	if len(pts) != 6 {
+		t.Fatalf("got %v points, expected 6: %v", len(pts), pts)
+	}
+	if pts[2].Lon != 180 || pts[3].Lon != -180 {
+		t.Errorf("boundary points are %v and %v", pts[2], pts[3])
+	}
+	if math.Abs(pts[2].Lat+16.65) > 1e-9 || pts[2].Lat != pts[3].Lat {
+		t.Errorf("boundary points at latitudes %v and %v, expected -16.65", pts[2].Lat, pts[3].Lat)
+	}
+	for j := 1; j < len(pts); j++ {
+		if j != 3 && math.Abs(pts[j].Lon-pts[j-1].Lon) > 180 {
+			t.Errorf("segment %v still crosses the antimeridian", j-1)
+		}
+	}
+
+	ele, _ := db.RouteElevations(0)
+	if len(ele) != len(pts) || ele[2] != 30 || ele[3] != 30 {
+		t.Errorf("elevations are %v", ele)
+	}
+
+	after, _ := db.RouteLength(0)
+	if math.Abs(after-before) > 1e-6*before {
+		t.Errorf("length changed from %v to %v", before, after)
+	}
+	if b := db.Bounds(); b.E != 180 || b.W != -180 {
+		t.Errorf("bounds are %v", *b)
+	}
+
+	// A second pass finds nothing more to do.
+	db.FixAntimeridian()
+	if len(db.routes[0].pts) != 6 {
+		t.Errorf("second pass changed the route: %v", db.routes[0].pts)
+	}
+}
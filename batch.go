@@ -0,0 +1,65 @@
+package routedb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// unpackLatLons returns the points packed into latlons as pairs of
+// little endian float64s, latitude first, checking they are valid.
+func unpackLatLons(latlons []byte) ([]Stop, error) {
+	if len(latlons)%16 != 0 {
+		return nil, errors.New("packed coordinates must be a multiple of 16 bytes")
+	}
+	points := make([]Stop, len(latlons)/16)
+	for k := range points {
+		lat := math.Float64frombits(binary.LittleEndian.Uint64(latlons[16*k:]))
+		lon := math.Float64frombits(binary.LittleEndian.Uint64(latlons[16*k+8:]))
+		if err := checkLatLon(lat, lon); err != nil {
+			return nil, fmt.Errorf("Point %v: %v", k, err)
+		}
+		points[k] = Stop{lat, lon}
+	}
+	return points, nil
+}
+
+// BatchNearest answers many Nearest queries in one call, saving the
+// overhead of crossing from Java to Go for each point of a long trace.
+// As for Nearest, the answers are boarding points: named stops where
+// the routes have them, and otherwise waypoints. The points are packed
+// into latlons as pairs of little endian float64s, latitude first, and
+// the answers are returned in the same order as a FlatBuffer holding a
+// StopList as for NearestN, each with its distance in meters from its
+// point. The search for each point starts from the answer for the one
+// before, so it is quickest when consecutive points are close
+// together, as along a recorded trace.
+func (db *Db) BatchNearest(latlons []byte) ([]byte, error) {
+	points, err := unpackLatLons(latlons)
+	if err != nil {
+		return nil, err
+	}
+	// The index of the boarding points, if there is one.
+	tree := db.stopIndex
+	if tree == nil && !db.hasNamedStops() {
+		tree = db.index
+	}
+	hits := make([]hit, len(points))
+	for k, p := range points {
+		var ri, pi int
+		var d float64
+		if tree != nil && k > 0 {
+			// Points of a trace are close together, so the
+			// answer for one is a good start for the next.
+			ri, pi, d = tree.nearestFrom(p, hits[k-1])
+		} else {
+			ri, pi, d = db.nearestBoarding(p)
+		}
+		if ri < 0 {
+			return nil, errNoStop
+		}
+		hits[k] = hit{db.routes[ri].boardingPoint(pi), ri, pi, d}
+	}
+	return finishStopList(hits), nil
+}
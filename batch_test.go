@@ -0,0 +1,92 @@
+package routedb
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+// pack packs latlons as taken by BatchNearest.
+func pack(latlons ...float64) []byte {
+	buf := make([]byte, 8*len(latlons))
+	for k, v := range latlons {
+		binary.LittleEndian.PutUint64(buf[8*k:], math.Float64bits(v))
+	}
+	return buf
+}
+
+func TestBatchNearest(t *testing.T) {
+	queries := []float64{40.50263, 72.821976, 40.52, 72.81, 40.5, 72.8}
+	buf, err := db.BatchNearest(pack(queries...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list := route.GetRootAsStopList(buf, 0)
+	if list.StopsLength() != 3 || list.MetersLength() != 3 {
+		t.Fatalf("got %v answers, expected 3", list.StopsLength())
+	}
+	var pt route.GeoPoint
+	for k := 0; k < 3; k++ {
+		s, err := db.Nearest(queries[2*k], queries[2*k+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		list.Stops(&pt, k)
+		if pt.Lat() != micro(s.Lat) || pt.Lon() != micro(s.Lon) {
+			t.Errorf("answer %v is %v, %v, expected %v", k, pt.Lat(), pt.Lon(), *s)
+		}
+		d := distance(Stop{queries[2*k], queries[2*k+1]}, *s)
+		if math.Abs(float64(list.Meters(k))-d) > 0.01 {
+			t.Errorf("answer %v is %v meters away, expected %v", k, list.Meters(k), d)
+		}
+	}
+
+	if buf, err := db.BatchNearest(nil); err != nil || route.GetRootAsStopList(buf, 0).StopsLength() != 0 {
+		t.Errorf("expected no answers, %v", err)
+	}
+	if _, err := db.BatchNearest(pack(40.5)); err == nil {
+		t.Error("expected error for odd number of coordinates")
+	}
+	if _, err := db.BatchNearest(pack(40.5, 72.8, 95, 72.8)); err == nil {
+		t.Error("expected error for invalid latitude")
+	}
+	// The answers are named stops where there are any, as for
+	// Nearest, and not the waypoints between them.
+	for _, opts := range []*LoadOptions{nil, {NoSpatialIndex: true}} {
+		sdb, err := LoadWithOptions(zipGPX(t, stopsGPX...), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		queries := []float64{40.5049, 72.8049, 40.5051, 72.8051, 40.509, 72.809}
+		buf, err := sdb.BatchNearest(pack(queries...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := route.GetRootAsStopList(buf, 0)
+		f := NewFederation(sdb)
+		for k := 0; k < list.StopsLength(); k++ {
+			lat, lon := queries[2*k], queries[2*k+1]
+			s, _ := sdb.Nearest(lat, lon)
+			list.Stops(&pt, k)
+			if pt.Lat() != micro(s.Lat) || pt.Lon() != micro(s.Lon) {
+				t.Errorf("stops answer %v is %v, %v, expected %v", k, pt.Lat(), pt.Lon(), *s)
+			}
+			// So are those of the other variants of Nearest.
+			if fs, err := f.Nearest(lat, lon); err != nil || *fs != *s {
+				t.Errorf("federation answer %v is %v, %v, expected %v", k, fs, err, *s)
+			}
+			if cs, err := sdb.NearestInCity(lat, lon, "osh"); err != nil || *cs != *s {
+				t.Errorf("city answer %v is %v, %v, expected %v", k, cs, err, *s)
+			}
+			if ps, _, err := sdb.NearestPreferRoute(lat, lon, 1, 0); err != nil || *ps != *s {
+				t.Errorf("preferred answer %v is %v, %v, expected %v", k, ps, err, *s)
+			}
+		}
+	}
+
+	if _, err := (&Db{}).BatchNearest(pack(40.5, 72.8)); err != errNoStop {
+		t.Errorf("expected no stop error, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package routedb
+
+import (
+	"errors"
+	"math"
+)
+
+// loopMeters is how close the two ends of a route must be for the
+// route to be considered a loop.
+const loopMeters = 50
+
+// isCircular reports whether the path pts, of the given length, is a
+// loop: its ends are within loopMeters of each other, but the path is
+// long enough to have gone somewhere and come back.
+func isCircular(pts []Stop, length float64) bool {
+	return len(pts) > 1 && length > 2*loopMeters && distance(pts[0], pts[len(pts)-1]) < loopMeters
+}
+
+// IsCircular reports whether route i is a loop, such as a ring line,
+// whose vehicles go round and round rather than turning back at a
+// terminus: its two ends are within loopMeters of each other, and it
+// is more than twice that long. It is computed when the route is
+// loaded, and kept current as the route changes. The Route FlatBuffer
+// carries the same flag.
+func (db *Db) IsCircular(i int) (bool, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return false, err
+	}
+	return t.circular, nil
+}
+
+// RouteGeneralBearing returns the overall direction of travel of
+// route i: the bearing in degrees, clockwise from north, from its
+// first waypoint to its last. A loop route, whose ends are within
+// loopMeters of each other, has no general bearing and returns an
+// error.
+func (db *Db) RouteGeneralBearing(i int) (float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, err
+	}
+	if len(t.pts) == 0 {
+		return 0, errEmptyRoute
+	}
+	first, last := t.pts[0], t.pts[len(t.pts)-1]
+	if distance(first, last) < loopMeters {
+		return 0, errors.New("route is a loop")
+	}
+	return bearing(first, last), nil
+}
+
+// backtrackDegrees is the change of bearing at a waypoint beyond which
+// the route is taken to double back on itself.
+const backtrackDegrees = 150
+
+// RouteBacktracks returns the indices of the waypoints of route i
+// where the path doubles back on itself, turning by more than 150
+// degrees, with one of the segments either side no longer than
+// toleranceMeters. Such short reversals are usually mistakes made
+// editing the feed, rather than real turns.
+func (db *Db) RouteBacktracks(i int, toleranceMeters float64) ([]int, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	idx := []int{}
+	for j := 1; j+1 < len(t.pts); j++ {
+		a, b, c := t.pts[j-1], t.pts[j], t.pts[j+1]
+		dab, dbc := distance(a, b), distance(b, c)
+		if dab == 0 || dbc == 0 {
+			continue
+		}
+		if dab > toleranceMeters && dbc > toleranceMeters {
+			continue
+		}
+		turn := math.Abs(bearing(b, c) - bearing(a, b))
+		if turn > 180 {
+			turn = 360 - turn
+		}
+		if turn > backtrackDegrees {
+			idx = append(idx, j)
+		}
+	}
+	return idx, nil
+}
+
+// RouteHeadingAt returns the heading of travel in degrees, clockwise
+// from north, at waypoint j of route i: the bearing on to the next
+// waypoint at a different place, or at the end of the route, the
+// bearing from the last one before it. Use it to orient direction
+// arrows, or to tell which way a vehicle at a waypoint is going.
+func (db *Db) RouteHeadingAt(i, j int) (float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, err
+	}
+	if j < 0 || j >= len(t.pts) {
+		return 0, errOutOfRange
+	}
+	for k := j + 1; k < len(t.pts); k++ {
+		if t.pts[k] != t.pts[j] {
+			return bearing(t.pts[j], t.pts[k]), nil
+		}
+	}
+	for k := j - 1; k >= 0; k-- {
+		if t.pts[k] != t.pts[j] {
+			return bearing(t.pts[k], t.pts[j]), nil
+		}
+	}
+	return 0, errors.New("route has no direction")
+}
+
+// RouteHeadingAtDistance is like RouteHeadingAt, but gives the heading
+// meters along route i from its start, as returned by PointAtDistance.
+func (db *Db) RouteHeadingAtDistance(i int, meters float64) (float64, error) {
+	_, b, err := db.PointAtDistance(i, meters)
+	return b, err
+}
@@ -0,0 +1,124 @@
+package routedb
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestRouteGeneralBearing(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-east", []float64{40.5, 72.80, 40.501, 72.81, 40.499, 72.82, 40.5, 72.83}},
+		testRoute{"kg-osh-loop", []float64{40.5, 72.80, 40.51, 72.81, 40.5, 72.8001}},
+	)
+
+	b, err := db.RouteGeneralBearing(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(b-90) > 1 {
+		t.Errorf("bearing is %v, expected about 90", b)
+	}
+
+	if _, err := db.RouteGeneralBearing(1); err == nil {
+		t.Error("expected error for loop route")
+	}
+	if _, err := db.RouteGeneralBearing(2); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRouteBacktracks(t *testing.T) {
+	db := makeDb(t, testRoute{"kg-osh-1", []float64{
+		40.5, 72.800, 40.5, 72.801, 40.5, 72.802,
+		40.5, 72.8015, // a step back
+		40.5, 72.803, 40.5, 72.804, 40.501, 72.804,
+	}})
+
+	idx, err := db.RouteBacktracks(0, 50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(idx) != "[2 3]" {
+		t.Errorf("backtracks are %v, expected [2 3]", idx)
+	}
+
+	// With a tighter tolerance, the 42 m step back is a real turn.
+	idx, err = db.RouteBacktracks(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(idx) != 0 {
+		t.Errorf("backtracks are %v, expected none", idx)
+	}
+
+	if _, err := db.RouteBacktracks(1, 50); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRouteHeadingAt(t *testing.T) {
+	db := makeDb(t,
+		// East, a repeated waypoint, then north.
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.5, 72.81, 40.51, 72.81}},
+		testRoute{"kg-osh-2", []float64{40.5, 72.80, 40.5, 72.80}},
+	)
+	for j, want := range []float64{90, 0, 0, 0} {
+		h, err := db.RouteHeadingAt(0, j)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(h-want) > 0.1 && math.Abs(h-want-360) > 0.1 {
+			t.Errorf("heading at %v is %v, expected %v", j, h, want)
+		}
+	}
+	if h, err := db.RouteHeadingAtDistance(0, 100); err != nil || math.Abs(h-90) > 0.1 {
+		t.Errorf("heading 100 m along is %v, %v, expected 90", h, err)
+	}
+
+	if _, err := db.RouteHeadingAt(1, 0); err == nil {
+		t.Error("expected error for route with no direction")
+	}
+	if _, err := db.RouteHeadingAt(0, 4); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if _, err := db.RouteHeadingAt(2, 0); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
+
+func TestIsCircular(t *testing.T) {
+	sdb := makeDb(t,
+		// Round a block and back to the start.
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.51, 72.81, 40.51, 72.80, 40.5001, 72.80}},
+		testRoute{"kg-osh-2", []float64{40.5, 72.80, 40.5, 72.81}},
+		// Ends close together, but too short to be a loop.
+		testRoute{"kg-osh-3", []float64{40.5, 72.80, 40.5003, 72.80, 40.5001, 72.80}},
+		testRoute{"kg-osh-4", []float64{40.5, 72.80}},
+	)
+	for i, want := range []bool{true, false, false, false} {
+		c, err := sdb.IsCircular(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != want {
+			t.Errorf("route %v circular is %v, expected %v", i, c, want)
+		}
+		buf, _ := sdb.Route(i)
+		if c := route.GetRootAsRoute(buf, 0).Circular(); c != want {
+			t.Errorf("route %v FlatBuffer circular is %v, expected %v", i, c, want)
+		}
+	}
+	if _, err := sdb.IsCircular(4); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+
+	// The flag follows changes to the route.
+	if i, err := sdb.JoinRoutes(1, 0); err != nil {
+		t.Fatal(err)
+	} else if c, _ := sdb.IsCircular(i); c {
+		t.Error("joined route is circular")
+	}
+}
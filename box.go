@@ -0,0 +1,147 @@
+package routedb
+
+import (
+	"math"
+	"sort"
+)
+
+// RouteFractionInBox returns the fraction, from 0 to 1, of the length
+// of route i lying inside box b. Segments crossing the edge of the box
+// are clipped to it, treating latitude and longitude as a plane, so
+// that the part inside is counted. A route of no length has nothing
+// inside.
+func (db *Db) RouteFractionInBox(i int, b *Box) (float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, err
+	}
+	if t.length == 0 {
+		return 0, nil
+	}
+	inside := 0.0
+	for j := 1; j < len(t.pts); j++ {
+		p, q := t.pts[j-1], t.pts[j]
+		t0, t1, ok := clipRange(p.Lon, p.Lat, q.Lon, q.Lat, b.W, b.S, b.E, b.N)
+		if ok {
+			inside += (t1 - t0) * distance(p, q)
+		}
+	}
+	return inside / t.length, nil
+}
+
+// RobustBounds returns a box bounding the central fraction percentile
+// (from 0 to 1) of the waypoints, ignoring outliers such as a bad GPS
+// fix at 0, 0, which would stretch Bounds across the globe. Latitudes
+// and longitudes are treated separately: the box runs between the
+// quantiles leaving (1-percentile)/2 of the waypoints to either side.
+func (db *Db) RobustBounds(percentile float64) *Box {
+	var lats, lons []float64
+	for _, t := range db.routes {
+		for _, pt := range t.pts {
+			lats = append(lats, pt.Lat)
+			lons = append(lons, pt.Lon)
+		}
+	}
+	if len(lats) == 0 {
+		return &Box{}
+	}
+	sort.Float64s(lats)
+	sort.Float64s(lons)
+
+	percentile = math.Max(0, math.Min(1, percentile))
+	lo := (1 - percentile) / 2
+	hi := 1 - lo
+	return &Box{
+		N: quantile(lats, hi),
+		E: quantile(lons, hi),
+		S: quantile(lats, lo),
+		W: quantile(lons, lo),
+	}
+}
+
+// quantile returns the q quantile of the sorted values vs, by the
+// nearest rank.
+func quantile(vs []float64, q float64) float64 {
+	k := int(math.Floor(q*float64(len(vs)-1) + 0.5))
+	return vs[k]
+}
+
+const (
+	// tilePixels is the size in pixels of a slippy map tile.
+	tilePixels = 256
+
+	// maxFitZoom is the zoom FitZoom returns for boxes too small to
+	// fill the viewport at any practical zoom.
+	maxFitZoom = 18
+)
+
+// mercatorY returns the Web Mercator y coordinate of latitude lat,
+// from 0 at the top of the map to 1 at the bottom.
+func mercatorY(lat float64) float64 {
+	lat = math.Max(-85.0511, math.Min(85.0511, lat)) * math.Pi / 180
+	return (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2
+}
+
+// FitZoom returns the highest slippy map zoom level, from 0 to 18, at
+// which box b fits in a viewport of the given size in pixels, using
+// 256 pixel Web Mercator tiles. A box of no size, such as the zero
+// Box, fits at zoom 18.
+func (b *Box) FitZoom(viewportWidthPx, viewportHeightPx int) int {
+	if viewportWidthPx <= 0 || viewportHeightPx <= 0 {
+		return 0
+	}
+	fx := (b.E - b.W) / 360
+	fy := mercatorY(b.S) - mercatorY(b.N)
+	z := math.Inf(1)
+	if fx > 0 {
+		z = math.Log2(float64(viewportWidthPx) / (tilePixels * fx))
+	}
+	if fy > 0 {
+		z = math.Min(z, math.Log2(float64(viewportHeightPx)/(tilePixels*fy)))
+	}
+	if z >= maxFitZoom {
+		return maxFitZoom
+	}
+	if z < 0 {
+		return 0
+	}
+	return int(z)
+}
+
+// overlaps reports whether boxes a and b have any point in common.
+func (a *Box) overlaps(b *Box) bool {
+	return a.S <= b.N && b.S <= a.N && a.W <= b.E && b.W <= a.E
+}
+
+// RoutesInBox returns the indices of the routes whose paths cross or
+// lie in box b, such as the map viewport, so only the routes visible
+// in it need be drawn. A route passing through b between two waypoints
+// outside it is included. Segments are treated as straight in latitude
+// and longitude, as a map draws them.
+func (db *Db) RoutesInBox(b *Box) []int {
+	routes := []int{}
+	for i, t := range db.routes {
+		if len(t.pts) == 0 || !t.bounds.overlaps(b) {
+			continue
+		}
+		if pathInBox(t.pts, b) {
+			routes = append(routes, i)
+		}
+	}
+	return routes
+}
+
+// pathInBox reports whether any part of the path pts lies in box b.
+func pathInBox(pts []Stop, b *Box) bool {
+	if len(pts) == 1 {
+		p := pts[0]
+		return p.Lat >= b.S && p.Lat <= b.N && p.Lon >= b.W && p.Lon <= b.E
+	}
+	for j := 1; j < len(pts); j++ {
+		p, q := pts[j-1], pts[j]
+		if _, _, ok := clipRange(p.Lon, p.Lat, q.Lon, q.Lat, b.W, b.S, b.E, b.N); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRouteFractionInBox(t *testing.T) {
+	db := makeDb(t, testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.5, 72.82, 40.5, 72.83}})
+
+	for _, tc := range []struct {
+		b   Box
+		exp float64
+	}{
+		// West half, cutting the middle segment.
+		{Box{N: 40.6, E: 72.815, S: 40.4, W: 72.7}, 0.5},
+		{Box{N: 40.6, E: 72.9, S: 40.4, W: 72.7}, 1},
+		{Box{N: 41.6, E: 73.9, S: 41.4, W: 73.7}, 0},
+		// The middle third.
+		{Box{N: 40.6, E: 72.82, S: 40.4, W: 72.81}, 1.0 / 3},
+	} {
+		f, err := db.RouteFractionInBox(0, &tc.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(f-tc.exp) > 0.001 {
+			t.Errorf("fraction in %v is %v, expected %v", tc.b, f, tc.exp)
+		}
+	}
+
+	if _, err := db.RouteFractionInBox(1, &Box{}); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRobustBounds(t *testing.T) {
+	var latlon []float64
+	for j := 0; j <= 100; j++ {
+		latlon = append(latlon, 40.5+float64(j)*0.0001, 72.8+float64(j)*0.0001)
+	}
+	db := makeDb(t,
+		testRoute{"kg-osh-1", latlon},
+		testRoute{"kg-osh-bad", []float64{0, 0}},
+	)
+
+	if b := db.Bounds(); b.S != 0 || b.W != 0 {
+		t.Errorf("bounds %v do not include the outlier", *b)
+	}
+	b := db.RobustBounds(0.95)
+	if b.S < 40.5 || b.W < 72.8 || b.N > 40.51 || b.E > 72.81 {
+		t.Errorf("robust bounds %v include the outlier", *b)
+	}
+	if b.N-b.S < 0.009 || b.E-b.W < 0.009 {
+		t.Errorf("robust bounds %v do not cover the route", *b)
+	}
+
+	if b := db.RobustBounds(1); *b != *db.Bounds() {
+		t.Errorf("robust bounds of everything are %v, expected %v", *b, *db.Bounds())
+	}
+	if b := (&Db{}).RobustBounds(0.95); *b != (Box{}) {
+		t.Errorf("robust bounds of empty db are %v", *b)
+	}
+}
+
+func TestFitZoom(t *testing.T) {
+	for _, tc := range []struct {
+		b    Box
+		w, h int
+		want int
+	}{
+		{*db.Bounds(), 1024, 1024, 14},
+		{*db.Bounds(), 256, 256, 12},
+		{Box{}, 1024, 1024, 18},
+		{Box{N: 85, E: 180, S: -85, W: -180}, 256, 256, 0},
+		{Box{N: 85, E: 180, S: -85, W: -180}, 1024, 1024, 2},
+		{*db.Bounds(), 0, 1024, 0},
+	} {
+		if z := tc.b.FitZoom(tc.w, tc.h); z != tc.want {
+			t.Errorf("%v in %vx%v: zoom %v, expected %v", tc.b, tc.w, tc.h, z, tc.want)
+		}
+	}
+}
+
+func TestRoutesInBox(t *testing.T) {
+	db := makeDb(t,
+		// Crosses the box without a waypoint in it.
+		testRoute{"kg-osh-across", []float64{40.505, 72.79, 40.505, 72.83}},
+		testRoute{"kg-osh-inside", []float64{40.505, 72.805}},
+		// Its bounds overlap the box, but it passes by a corner.
+		testRoute{"kg-osh-corner", []float64{40.52, 72.80, 40.50, 72.78}},
+		testRoute{"kg-osh-away", []float64{40.6, 72.9, 40.7, 72.9}},
+	)
+	b := &Box{N: 40.51, E: 72.81, S: 40.50, W: 72.80}
+	got := db.RoutesInBox(b)
+	if len(got) != 2 || got[0] != 0 || got[1] != 1 {
+		t.Errorf("routes in box are %v, expected [0 1]", got)
+	}
+	if got := db.RoutesInBox(&Box{}); len(got) != 0 {
+		t.Errorf("routes in zero box are %v", got)
+	}
+	if got := db.RoutesInBox(db.Bounds()); len(got) != 4 {
+		t.Errorf("routes in bounds are %v, expected all", got)
+	}
+}
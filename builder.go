@@ -0,0 +1,47 @@
+package routedb
+
+import "errors"
+
+// A Builder assembles a Db from routes given directly, rather than
+// loaded from GPX files.
+type Builder struct {
+	routes []*track
+}
+
+// NewBuilder returns an empty Builder.
+func NewBuilder() *Builder {
+	return &Builder{}
+}
+
+// AddRoute adds a route with the given metadata, following the
+// waypoints given by the parallel slices lats and lons.
+func (b *Builder) AddRoute(country, city, name string, lats, lons []float64) error {
+	if len(lats) != len(lons) {
+		return errors.New("lats and lons differ in length")
+	}
+	t := &track{
+		md:  country + "-" + city + "-" + name,
+		pts: make([]Stop, len(lats)),
+	}
+	for j := range lats {
+		if err := checkLatLon(lats[j], lons[j]); err != nil {
+			return err
+		}
+		t.pts[j] = Stop{Lat: lats[j], Lon: lons[j]}
+	}
+	t.update()
+	b.routes = append(b.routes, t)
+	return nil
+}
+
+// Build returns a Db holding the routes added so far.
+func (b *Builder) Build() (*Db, error) {
+	db := &Db{routes: make([]*track, len(b.routes))}
+	for k, t := range b.routes {
+		c := *t
+		db.routes[k] = &c
+	}
+	db.assignIDs()
+	db.RecomputeBounds()
+	return db, nil
+}
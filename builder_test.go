@@ -0,0 +1,66 @@
+package routedb
+
+import (
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder()
+	if err := b.AddRoute("kg", "osh", "1", []float64{40.5, 40.51}, []float64{72.8, 72.81}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddRoute("kg", "bishkek", "2", []float64{42.87, 42.88, 42.89}, []float64{74.59, 74.6, 74.61}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AddRoute("kg", "osh", "bad", []float64{40.5}, []float64{}); err == nil {
+		t.Error("expected error for mismatched lengths")
+	}
+	db, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if db.Routes() != 2 {
+		t.Errorf("got %v routes, expected 2", db.Routes())
+	}
+	if *db.Bounds() != (Box{N: 42.89, E: 74.61, S: 40.5, W: 72.8}) {
+		t.Errorf("bounds are %v", *db.Bounds())
+	}
+
+	buf, err := db.Route(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := route.GetRootAsRoute(buf, 0)
+	if string(r.City()) != "bishkek" || string(r.Name()) != "2" || r.PathLength() != 3 {
+		t.Errorf("unexpected route %v/%v with %v points", string(r.City()), string(r.Name()), r.PathLength())
+	}
+
+	n, err := db.Nearest(40.509, 72.809)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *n != (Stop{40.51, 72.81}) {
+		t.Errorf("nearest is %v", *n)
+	}
+}
+
+func TestRecomputeBounds(t *testing.T) {
+	b := NewBuilder()
+	b.AddRoute("kg", "osh", "1", []float64{40.5, 40.51}, []float64{72.8, 72.81})
+	db, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db.routes[0].pts[1] = Stop{40.6, 72.9}
+	if *db.Bounds() != (Box{N: 40.51, E: 72.81, S: 40.5, W: 72.8}) {
+		t.Errorf("bounds changed before recomputing: %v", *db.Bounds())
+	}
+	db.RecomputeBounds()
+	if *db.Bounds() != (Box{N: 40.6, E: 72.9, S: 40.5, W: 72.8}) {
+		t.Errorf("recomputed bounds are %v", *db.Bounds())
+	}
+}
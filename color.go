@@ -0,0 +1,34 @@
+package routedb
+
+import "strings"
+
+// normalizeColor returns the hex color c, with or without a leading
+// "#", as six upper case hex digits, or the empty string if c is not
+// such a color.
+func normalizeColor(c string) string {
+	c = strings.TrimPrefix(strings.TrimSpace(c), "#")
+	if len(c) != 6 {
+		return ""
+	}
+	for _, r := range c {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return ""
+		}
+	}
+	return strings.ToUpper(c)
+}
+
+// RouteColors returns the color in which to draw route i, and the
+// color of text drawn over it, such as the route's name on a badge,
+// each as six hex digits RRGGBB, as in GTFS. They are taken from the
+// extensions of the track in the route's GPX file, as described for
+// trackExtensions, and are also in the Route FlatBuffer, so that every
+// app draws a line in the same color. A color not given is returned as
+// the empty string, leaving the choice to the app.
+func (db *Db) RouteColors(i int) (color, textColor string, err error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return "", "", err
+	}
+	return t.color, t.textColor, nil
+}
@@ -0,0 +1,47 @@
+package routedb
+
+import (
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestRouteColors(t *testing.T) {
+	sdb, err := Load(zipGPX(t, `<gpx xmlns:gpx_style="http://www.topografix.com/GPX/gpx_style/0/2">
+<metadata><name>kg-osh-1</name></metadata>
+<trk><extensions><color>#00a0e0</color><text_color>ffffff</text_color></extensions>
+<trkseg><trkpt lat="40.5" lon="72.8"/></trkseg></trk></gpx>`, `<gpx xmlns:gpx_style="http://www.topografix.com/GPX/gpx_style/0/2">
+<metadata><name>kg-osh-2</name></metadata>
+<trk><extensions><gpx_style:line><gpx_style:color>FF0000</gpx_style:color></gpx_style:line></extensions>
+<trkseg><trkpt lat="40.5" lon="72.8"/></trkseg></trk></gpx>`, `<gpx>
+<metadata><name>kg-osh-3</name></metadata>
+<trk><extensions><color>red</color></extensions>
+<trkseg><trkpt lat="40.5" lon="72.8"/></trkseg></trk></gpx>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range [][2]string{
+		{"00A0E0", "FFFFFF"},
+		{"FF0000", ""},
+		{"", ""},
+	} {
+		c, tc, err := sdb.RouteColors(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c != want[0] || tc != want[1] {
+			t.Errorf("route %v colors are %q, %q, expected %q", i, c, tc, want)
+		}
+		buf, _ := sdb.Route(i)
+		r := route.GetRootAsRoute(buf, 0)
+		if string(r.Color()) != want[0] || string(r.TextColor()) != want[1] {
+			t.Errorf("route %v FlatBuffer colors are %q, %q, expected %q", i, r.Color(), r.TextColor(), want)
+		}
+	}
+	if c, tc, err := db.RouteColors(0); c != "" || tc != "" || err != nil {
+		t.Errorf("fixture colors are %q, %q, %v", c, tc, err)
+	}
+	if _, _, err := sdb.RouteColors(3); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package routedb
+
+import "math"
+
+// RoutesInCorridor returns the indices of the routes running within
+// bufferMeters of the polyline given by the parallel slices lats and
+// lons, such as a planned car trip, for at least minMeters of their
+// length: the transit alternatives to the trip. A route merely
+// crossing the polyline, or touching it briefly, is left out.
+//
+// The length of a route inside the corridor is measured by walking
+// its path in steps of at most half of bufferMeters and counting the
+// steps whose middle lies within bufferMeters of the polyline.
+func (db *Db) RoutesInCorridor(lats, lons []float64, bufferMeters, minMeters float64) ([]int, error) {
+	line, err := traceStops(lats, lons)
+	if err != nil {
+		return nil, err
+	}
+	// A box around the corridor, to skip the routes far from it.
+	box := boundsOf([]*track{{pts: line}})
+	margin := bufferMeters / metersPerDegree
+	box.N, box.S = box.N+margin, box.S-margin
+	k := math.Cos(math.Max(math.Abs(box.N), math.Abs(box.S)) * math.Pi / 180)
+	box.E, box.W = box.E+margin/math.Max(k, 1e-6), box.W-margin/math.Max(k, 1e-6)
+
+	step := math.Max(bufferMeters/2, 1)
+	routes := []int{}
+	for i, t := range db.routes {
+		if len(t.pts) < 2 || !t.bounds.overlaps(&box) {
+			continue
+		}
+		inside := 0.0
+		for j := 1; j < len(t.pts); j++ {
+			a, b := t.pts[j-1], t.pts[j]
+			l := distance(a, b)
+			n := math.Ceil(l / step)
+			for s := 0.0; s < n; s++ {
+				mid := interpolate(a, b, (s+0.5)/n)
+				if _, _, _, d := snap(mid, line); d <= bufferMeters {
+					inside += l / n
+				}
+			}
+		}
+		if inside >= minMeters {
+			routes = append(routes, i)
+		}
+	}
+	return routes, nil
+}
@@ -0,0 +1,40 @@
+package routedb
+
+import "testing"
+
+func TestRoutesInCorridor(t *testing.T) {
+	db := makeDb(t,
+		// Runs alongside the trip, 30 meters north of it.
+		testRoute{"kg-osh-along", []float64{40.50027, 72.80, 40.50027, 72.82}},
+		// Crosses the trip.
+		testRoute{"kg-osh-across", []float64{40.49, 72.81, 40.51, 72.81}},
+		// Alongside for only part of the way, then leaves it.
+		testRoute{"kg-osh-part", []float64{40.5, 72.795, 40.5, 72.802, 40.52, 72.802}},
+		testRoute{"kg-osh-far", []float64{40.6, 72.80, 40.6, 72.82}},
+	)
+	lats := []float64{40.5, 40.5, 40.5}
+	lons := []float64{72.80, 72.81, 72.82}
+
+	got, err := db.RoutesInCorridor(lats, lons, 50, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != 0 {
+		t.Errorf("routes in corridor %v, expected [0]", got)
+	}
+	got, _ = db.RoutesInCorridor(lats, lons, 50, 150)
+	if len(got) != 2 || got[0] != 0 || got[1] != 2 {
+		t.Errorf("routes in corridor %v, expected [0 2]", got)
+	}
+	got, _ = db.RoutesInCorridor(lats, lons, 10, 100)
+	if len(got) != 1 || got[0] != 2 {
+		t.Errorf("routes in narrow corridor %v, expected [2]", got)
+	}
+
+	if _, err := db.RoutesInCorridor(lats, lons[:2], 50, 100); err == nil {
+		t.Error("expected error for mismatched slices")
+	}
+	if _, err := db.RoutesInCorridor(nil, nil, 50, 100); err == nil {
+		t.Error("expected error for empty polyline")
+	}
+}
@@ -0,0 +1,95 @@
+package routedb
+
+import "fmt"
+
+// A country is a country of ISO 3166-1.
+type country struct {
+	code    string   // alpha-2 code, lower case, as used in routedb
+	alpha3  string   // alpha-3 code, lower case
+	name    string   // English short name
+	aliases []string // other English names
+}
+
+// countryByKey finds the countries by code and name, normalized as by
+// normalizeKey.
+var countryByKey = indexCountries()
+
+func indexCountries() map[string]*country {
+	m := make(map[string]*country)
+	for k := range countries {
+		c := &countries[k]
+		for _, key := range append([]string{c.code, c.alpha3, c.name}, c.aliases...) {
+			m[normalizeKey(key)] = c
+		}
+	}
+	return m
+}
+
+// lookupCountry returns the country given by its alpha-2 or alpha-3
+// code, or by its English name, ignoring case and accents, or nil if
+// there is none.
+func lookupCountry(s string) *country {
+	return countryByKey[normalizeKey(s)]
+}
+
+// CountryCode returns the canonical form of the country given by its
+// ISO 3166-1 alpha-2 or alpha-3 code, or its English name, ignoring
+// case: its alpha-2 code, lower case, as in "kg" for "KG", "KGZ" and
+// "Kyrgyzstan". It is an error for there to be no such country.
+func CountryCode(country string) (string, error) {
+	c := lookupCountry(country)
+	if c == nil {
+		return "", fmt.Errorf("Unknown country %q", country)
+	}
+	return c.code, nil
+}
+
+// CountryName returns the English name of the country given as for
+// CountryCode.
+func CountryName(country string) (string, error) {
+	c := lookupCountry(country)
+	if c == nil {
+		return "", fmt.Errorf("Unknown country %q", country)
+	}
+	return c.name, nil
+}
+
+// normalizeCountries replaces the country of each route given by an
+// ISO 3166-1 code or name with its canonical form, as returned by
+// CountryCode, so that routes of the same country given as "kg", "KG"
+// and "kyrgyzstan" are found together. The country of a route which
+// is not a known country is left as it is, with a warning, or is an
+// error if strict is true. Routes whose metadata name is not of the
+// form country-city-name are left alone.
+func (db *Db) normalizeCountries(strict bool) error {
+	for _, t := range db.routes {
+		country, city, name := t.split()
+		if country == "" {
+			continue
+		}
+		c := lookupCountry(country)
+		if c == nil {
+			if strict {
+				return fmt.Errorf("Route %v has unknown country %q", t.md, country)
+			}
+			db.warnings = append(db.warnings, fmt.Sprintf("Route %v has unknown country %q", t.md, country))
+			continue
+		}
+		if c.code != country {
+			t.setKey(c.code, city, name)
+		}
+	}
+	return nil
+}
+
+// RouteCountry returns the country of route i: its canonical code, as
+// returned by CountryCode, if it is a known country, and otherwise as
+// given in the route's metadata.
+func (db *Db) RouteCountry(i int) (string, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return "", err
+	}
+	country, _, _ := t.split()
+	return country, nil
+}
@@ -0,0 +1,75 @@
+package routedb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountryCode(t *testing.T) {
+	for _, tc := range []struct {
+		in, code, name string
+	}{
+		{"kg", "kg", "Kyrgyzstan"},
+		{"KG", "kg", "Kyrgyzstan"},
+		{"KGZ", "kg", "Kyrgyzstan"},
+		{" kyrgyzstan ", "kg", "Kyrgyzstan"},
+		{"Kyrgyz Republic", "kg", "Kyrgyzstan"},
+		{"cote d'ivoire", "ci", "Côte d'Ivoire"},
+		{"Bolivia, Plurinational State of", "bo", "Bolivia"},
+	} {
+		code, err := CountryCode(tc.in)
+		if err != nil || code != tc.code {
+			t.Errorf("code of %q is %q, %v, expected %q", tc.in, code, err, tc.code)
+		}
+		name, err := CountryName(tc.in)
+		if err != nil || name != tc.name {
+			t.Errorf("name of %q is %q, %v, expected %q", tc.in, name, err, tc.name)
+		}
+	}
+	for _, in := range []string{"", "xx", "Narnia"} {
+		if _, err := CountryCode(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+		if _, err := CountryName(in); err == nil {
+			t.Errorf("%q: expected error", in)
+		}
+	}
+}
+
+func TestNormalizeCountries(t *testing.T) {
+	in := zipFiles(t,
+		"0.gpx", plainGPX("kg-osh-1"),
+		"1.gpx", plainGPX("KG-osh-2"),
+		"2.gpx", plainGPX("kyrgyzstan-osh-3"),
+		"3.gpx", plainGPX("narnia-cair paravel-1"),
+		"4.gpx", plainGPX("unnamed"),
+	)
+	sdb, err := Load(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"kg", "kg", "kg", "narnia", ""} {
+		if c, _ := sdb.RouteCountry(i); c != want {
+			t.Errorf("route %v country is %q, expected %q", i, c, want)
+		}
+	}
+	if i, ok := sdb.RouteByKey("kg-osh-3"); !ok || i != 2 {
+		t.Errorf("route kg-osh-3 is %v, %v", i, ok)
+	}
+	if id, _ := sdb.RouteID(1); id != "kg-osh-2" {
+		t.Errorf("route 1 id is %v", id)
+	}
+	if w := sdb.Warnings(); !strings.Contains(w, "narnia") || strings.Contains(w, "unnamed") {
+		t.Errorf("warnings are %q", w)
+	}
+	if _, err := sdb.RouteCountry(5); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+
+	if _, err := LoadWithOptions(in, &LoadOptions{StrictCountries: true}); err == nil {
+		t.Error("expected error in strict mode")
+	}
+	if _, err := LoadWithOptions(zipFiles(t, "0.gpx", plainGPX("KGZ-osh-1")), &LoadOptions{StrictCountries: true}); err != nil {
+		t.Errorf("strict mode: %v", err)
+	}
+}
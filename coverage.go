@@ -0,0 +1,27 @@
+package routedb
+
+import "math"
+
+// CoverageGrid counts the waypoints falling in each cell of a grid of
+// squares cellMeters on a side, for drawing a density overlay of where
+// the network reaches. Cells are keyed by their integer column and row
+// (x east, y north), counted from the south west corner of Bounds. The
+// grid is laid on an equirectangular projection scaled to true
+// distances at the middle latitude of the bounds, which is accurate at
+// the scale of a city. Cells with no waypoints are left out.
+func (db *Db) CoverageGrid(cellMeters float64) map[[2]int]int {
+	grid := make(map[[2]int]int)
+	if cellMeters <= 0 {
+		return grid
+	}
+	b := db.bounds
+	k := math.Cos((b.N + b.S) / 2 * math.Pi / 180)
+	for _, t := range db.routes {
+		for _, pt := range t.pts {
+			x := (pt.Lon - b.W) * k * metersPerDegree / cellMeters
+			y := (pt.Lat - b.S) * metersPerDegree / cellMeters
+			grid[[2]int{int(math.Floor(x)), int(math.Floor(y))}]++
+		}
+	}
+	return grid
+}
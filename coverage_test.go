@@ -0,0 +1,27 @@
+package routedb
+
+import "testing"
+
+func TestCoverageGrid(t *testing.T) {
+	grid := db.CoverageGrid(100)
+	total := 0
+	for cell, n := range grid {
+		if cell[0] < 0 || cell[1] < 0 {
+			t.Errorf("cell %v is outside the bounds", cell)
+		}
+		total += n
+	}
+	if total != db.TotalWaypoints() {
+		t.Errorf("grid holds %v waypoints, expected %v", total, db.TotalWaypoints())
+	}
+	if len(grid) < 2 || len(grid) >= total {
+		t.Errorf("%v waypoints fell in %v cells", total, len(grid))
+	}
+
+	// Two points 150 m apart north to south, with 100 m cells.
+	db := makeDb(t, testRoute{"kg-osh-1", []float64{40.5, 72.8, 40.5 + 150/metersPerDegree, 72.8}})
+	grid = db.CoverageGrid(100)
+	if len(grid) != 2 || grid[[2]int{0, 0}] != 1 || grid[[2]int{0, 1}] != 1 {
+		t.Errorf("grid is %v", grid)
+	}
+}
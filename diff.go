@@ -0,0 +1,47 @@
+package routedb
+
+// Diff compares two databases, matching their routes by metadata
+// name. It returns the indices into new of the routes added and of
+// those changed, meaning present in both but with different paths,
+// and the indices into old of the routes removed. Where several
+// routes share a name, they are matched in order.
+func Diff(old, new *Db) (added, removed, changed []int) {
+	byKey := make(map[string][]int)
+	for i, t := range old.routes {
+		byKey[t.md] = append(byKey[t.md], i)
+	}
+
+	matched := make([]bool, len(old.routes))
+	for i, t := range new.routes {
+		olds := byKey[t.md]
+		if len(olds) == 0 {
+			added = append(added, i)
+			continue
+		}
+		o := olds[0]
+		byKey[t.md] = olds[1:]
+		matched[o] = true
+		if !samePath(old.routes[o].pts, t.pts) {
+			changed = append(changed, i)
+		}
+	}
+	for i, m := range matched {
+		if !m {
+			removed = append(removed, i)
+		}
+	}
+	return
+}
+
+// samePath reports whether a and b are the same path.
+func samePath(a, b []Stop) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for j := range a {
+		if a[j] != b[j] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,49 @@
+package routedb
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testRoutes returns the routes of db, for building modified copies
+// of it with makeDb.
+func testRoutes(db *Db) []testRoute {
+	var routes []testRoute
+	for _, t := range db.routes {
+		r := testRoute{md: t.md}
+		for _, pt := range t.pts {
+			r.latlon = append(r.latlon, pt.Lat, pt.Lon)
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+func TestDiff(t *testing.T) {
+	old := loadTestdata(t, "testdata/cities.zip")
+
+	routes := testRoutes(old)
+	routes[1].latlon[0] += 0.001
+	routes = append(routes[:2], routes[3:]...)
+	routes = append(routes, testRoute{"kg-osh-new", []float64{40.5, 72.8, 40.6, 72.9}})
+	new := makeDb(t, routes...)
+
+	added, removed, changed := Diff(old, new)
+	for _, tc := range []struct {
+		name     string
+		got, exp []int
+	}{
+		{"added", added, []int{3}},
+		{"removed", removed, []int{2}},
+		{"changed", changed, []int{1}},
+	} {
+		if fmt.Sprint(tc.got) != fmt.Sprint(tc.exp) {
+			t.Errorf("%v is %v, expected %v", tc.name, tc.got, tc.exp)
+		}
+	}
+
+	added, removed, changed = Diff(old, old)
+	if len(added)+len(removed)+len(changed) != 0 {
+		t.Errorf("db differs from itself: %v %v %v", added, removed, changed)
+	}
+}
@@ -0,0 +1,94 @@
+package routedb
+
+import "strings"
+
+// The directions of travel a route can be tagged with. A line run in
+// both directions is often recorded as two GPX files with the same
+// metadata name, one tagged "outbound" and the other "inbound" in its
+// keywords.
+const (
+	dirOutbound = "outbound"
+	dirInbound  = "inbound"
+)
+
+// A RouteDirection is one direction variant of a line: a route with
+// the same metadata name as the others of the line.
+type RouteDirection struct {
+	RouteIndex int
+
+	// Direction is "outbound" or "inbound", from the route's tags,
+	// or empty if it is tagged with neither.
+	Direction string
+
+	// From and To are the first and last waypoints of the route,
+	// the termini it runs between.
+	From, To *Stop
+}
+
+// direction returns the direction t is tagged with, or "".
+func (t *track) direction() string {
+	for _, tag := range t.tags {
+		for _, d := range []string{dirOutbound, dirInbound} {
+			if strings.EqualFold(tag, d) {
+				return d
+			}
+		}
+	}
+	return ""
+}
+
+// sameLine reports whether a and b are direction variants of the same
+// line: whether their metadata names are the same, as compared by
+// RouteByKeyFuzzy. Routes without a metadata name are each a line of
+// their own.
+func sameLine(a, b *track) bool {
+	if a == b {
+		return true
+	}
+	ka, kb := normalizeKey(a.md), normalizeKey(b.md)
+	return ka != "" && ka == kb
+}
+
+// RouteDirections returns the direction variants of the line route i
+// belongs to, route i among them, in order of route index, with the
+// direction each travels in. A route with no other variants is
+// returned alone.
+func (db *Db) RouteDirections(i int) ([]*RouteDirection, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	var dirs []*RouteDirection
+	for j, u := range db.routes {
+		if !sameLine(t, u) {
+			continue
+		}
+		d := &RouteDirection{RouteIndex: j, Direction: u.direction()}
+		if len(u.pts) > 0 {
+			first, last := u.pts[0], u.pts[len(u.pts)-1]
+			d.From, d.To = &first, &last
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// Lines returns the index of one route of each line, the first of its
+// direction variants, in order of route index, for listing each line
+// once however many directions it is recorded in.
+func (db *Db) Lines() []int {
+	lines := []int{}
+	for i, t := range db.routes {
+		first := true
+		for _, u := range db.routes[:i] {
+			if sameLine(t, u) {
+				first = false
+				break
+			}
+		}
+		if first {
+			lines = append(lines, i)
+		}
+	}
+	return lines
+}
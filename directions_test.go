@@ -0,0 +1,57 @@
+package routedb
+
+import (
+	"fmt"
+	"testing"
+)
+
+// directionGPX returns a GPX file for a route with metadata name md,
+// keywords kw, and waypoints at the alternating lat, lon values.
+func directionGPX(md, kw string, latlon ...float64) string {
+	s := fmt.Sprintf("<gpx><metadata><name>%v</name><keywords>%v</keywords></metadata><trk><trkseg>\n", md, kw)
+	for j := 0; j+1 < len(latlon); j += 2 {
+		s += fmt.Sprintf("<trkpt lat=\"%v\" lon=\"%v\"/>\n", latlon[j], latlon[j+1])
+	}
+	return s + "</trkseg></trk></gpx>\n"
+}
+
+func TestRouteDirections(t *testing.T) {
+	sdb, err := Load(zipGPX(t,
+		directionGPX("kg-osh-7", "Outbound", 40.5, 72.8, 40.51, 72.81),
+		directionGPX("kg-osh-8", "", 40.6, 72.9, 40.61, 72.9),
+		directionGPX("kg-osh-7 ", "express, inbound", 40.51, 72.81, 40.5, 72.8),
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, i := range []int{0, 2} {
+		dirs, err := sdb.RouteDirections(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dirs) != 2 {
+			t.Fatalf("route %v has %v directions", i, len(dirs))
+		}
+		if dirs[0].RouteIndex != 0 || dirs[0].Direction != "outbound" ||
+			*dirs[0].From != (Stop{40.5, 72.8}) || *dirs[0].To != (Stop{40.51, 72.81}) {
+			t.Errorf("route %v first direction is %+v", i, dirs[0])
+		}
+		if dirs[1].RouteIndex != 2 || dirs[1].Direction != "inbound" ||
+			*dirs[1].From != (Stop{40.51, 72.81}) || *dirs[1].To != (Stop{40.5, 72.8}) {
+			t.Errorf("route %v second direction is %+v", i, dirs[1])
+		}
+	}
+
+	dirs, err := sdb.RouteDirections(1)
+	if err != nil || len(dirs) != 1 || dirs[0].RouteIndex != 1 || dirs[0].Direction != "" {
+		t.Errorf("single direction route has %v, %v", dirs, err)
+	}
+	if _, err := sdb.RouteDirections(3); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+
+	if lines := sdb.Lines(); fmt.Sprint(lines) != "[0 1]" {
+		t.Errorf("lines are %v", lines)
+	}
+}
@@ -0,0 +1,34 @@
+package routedb
+
+// RouteElevations returns the elevation in meters of each waypoint
+// of route i, parallel to its path, or nil if the route has no
+// elevation data.
+func (db *Db) RouteElevations(i int) ([]float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	if t.ele == nil {
+		return nil, nil
+	}
+	ele := make([]float64, len(t.ele))
+	copy(ele, t.ele)
+	return ele, nil
+}
+
+// RouteElevationGain returns the total climb in meters along route
+// i, summing only the positive changes in elevation. A route with no
+// elevation data has no gain.
+func (db *Db) RouteElevationGain(i int) (float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, err
+	}
+	gain := 0.0
+	for j := 1; j < len(t.ele); j++ {
+		if d := t.ele[j] - t.ele[j-1]; d > 0 {
+			gain += d
+		}
+	}
+	return gain, nil
+}
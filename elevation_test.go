@@ -0,0 +1,53 @@
+package routedb
+
+import "testing"
+
+func TestRouteElevations(t *testing.T) {
+	db := loadTestdata(t, "testdata/elevation.zip")
+
+	ele, err := db.RouteElevations(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []float64{100, 150, 120, 200}
+	if len(ele) != len(exp) {
+		t.Fatalf("got %v elevations, expected %v", len(ele), len(exp))
+	}
+	for j := range exp {
+		if ele[j] != exp[j] {
+			t.Errorf("elevation %v is %v, expected %v", j, ele[j], exp[j])
+		}
+	}
+
+	ele, err = db.RouteElevations(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ele != nil {
+		t.Errorf("route without elevation data returned %v", ele)
+	}
+
+	if _, err := db.RouteElevations(2); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRouteElevationGain(t *testing.T) {
+	db := loadTestdata(t, "testdata/elevation.zip")
+
+	gain, err := db.RouteElevationGain(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gain != 130 {
+		t.Errorf("gain is %v, expected 130", gain)
+	}
+
+	gain, err = db.RouteElevationGain(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gain != 0 {
+		t.Errorf("gain without elevation data is %v", gain)
+	}
+}
@@ -0,0 +1,40 @@
+package routedb
+
+import "errors"
+
+// EstimateArrival estimates the time in seconds for a vehicle at the
+// given position on route i to reach the given stop, travelling along
+// the route at speedMetersPerSecond, which may be assumed or measured.
+// Both the position and the stop are taken to be at the nearest points
+// on the route's path, and the distance between them is measured
+// along it, as by DistanceBetween. It is an error for the stop to
+// have been passed already, except on a circular route, as told by
+// IsCircular, where the vehicle reaches it on its next time round.
+func (db *Db) EstimateArrival(i int, lat, lon, stopLat, stopLon, speedMetersPerSecond float64) (float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkLatLon(lat, lon); err != nil {
+		return 0, err
+	}
+	if err := checkLatLon(stopLat, stopLon); err != nil {
+		return 0, err
+	}
+	if !(speedMetersPerSecond > 0) {
+		return 0, errors.New("speed must be positive")
+	}
+	if len(t.pts) == 0 {
+		return 0, errEmptyRoute
+	}
+	here := alongPath(t.pts, Stop{lat, lon})
+	stop := alongPath(t.pts, Stop{stopLat, stopLon})
+	if stop < here {
+		if !t.circular {
+			return 0, errors.New("stop already passed")
+		}
+		// Go round to the start of the loop, and on to the stop.
+		stop += t.length + distance(t.pts[len(t.pts)-1], t.pts[0])
+	}
+	return (stop - here) / speedMetersPerSecond, nil
+}
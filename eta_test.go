@@ -0,0 +1,55 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateArrival(t *testing.T) {
+	db := makeDb(t, testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.51, 72.80, 40.51, 72.81}})
+	up := distance(Stop{40.50, 72.80}, Stop{40.51, 72.80})
+	across := distance(Stop{40.51, 72.80}, Stop{40.51, 72.81})
+
+	// From the start to the end at 10 m/s, around the corner.
+	s, err := db.EstimateArrival(0, 40.50, 72.80, 40.51, 72.81, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (up + across) / 10; math.Abs(s-exp) > 0.1 {
+		t.Errorf("arrival in %v s, expected %v", s, exp)
+	}
+	// From half way up, a little off the route.
+	s, _ = db.EstimateArrival(0, 40.505, 72.8001, 40.51, 72.81, 5)
+	if exp := (up/2 + across) / 5; math.Abs(s-exp) > 0.2 {
+		t.Errorf("arrival in %v s, expected %v", s, exp)
+	}
+	if s, err := db.EstimateArrival(0, 40.505, 72.80, 40.505, 72.80, 5); err != nil || s != 0 {
+		t.Errorf("arrival at the stop in %v s, %v", s, err)
+	}
+
+	if _, err := db.EstimateArrival(0, 40.51, 72.81, 40.50, 72.80, 10); err == nil {
+		t.Error("expected error for passed stop")
+	}
+	if _, err := db.EstimateArrival(0, 40.50, 72.80, 40.51, 72.81, 0); err == nil {
+		t.Error("expected error for zero speed")
+	}
+	if _, err := db.EstimateArrival(1, 40.50, 72.80, 40.51, 72.81, 10); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
+
+func TestEstimateArrivalCircular(t *testing.T) {
+	db := makeDb(t, testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.51, 72.80, 40.51, 72.81, 40.5001, 72.81, 40.5001, 72.80}})
+	length, _ := db.RouteLength(0)
+	gap := distance(Stop{40.5001, 72.80}, Stop{40.50, 72.80})
+	up := distance(Stop{40.50, 72.80}, Stop{40.51, 72.80})
+
+	// From the top corner, round to the first corner and up again.
+	s, err := db.EstimateArrival(0, 40.51, 72.80, 40.505, 72.80, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := (length - up + gap + up/2) / 10; math.Abs(s-exp) > 0.2 {
+		t.Errorf("arrival in %v s, expected %v", s, exp)
+	}
+}
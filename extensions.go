@@ -0,0 +1,126 @@
+package routedb
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+)
+
+// gpxExtensions picks the route level extensions out of a GPX file.
+type gpxExtensions struct {
+	Gpx      extensionsBlock `xml:"extensions"`
+	Metadata extensionsBlock `xml:"metadata>extensions"`
+	Trk      extensionsBlock `xml:"trk>extensions"`
+}
+
+type extensionsBlock struct {
+	Inner []byte `xml:",innerxml"`
+}
+
+// trackExtensions picks the fields routedb understands out of the
+// extensions of the track in a GPX file:
+//
+//	<trk><extensions>
+//	  <color>00A0E0</color>           the line color, RRGGBB
+//	  <text_color>FFFFFF</text_color> the color of text over it
+//	  <agency>oshbus</agency>         the ID of the route's agency
+//	  <fare currency="KGS" flat="10" per_km="1.5" per_zone="5"/>
+//	  <wheelchair>yes</wheelchair>    or no; see RouteWheelchair
+//	  <name lang="ru">Маршрут 7</name> the name in a locale
+//	</extensions></trk>
+//
+// The colors are like the route_color and route_text_color of GTFS.
+// The line color may instead be given as in the gpx_style extension,
+// <line><color>. The attributes of the fare are the fields of Fare;
+// those not given are zero. The fare zone of a named stop, and whether
+// it can be used in a wheelchair, are given in the extensions of its
+// <wpt>, as <zone>2</zone> and <wheelchair>yes</wheelchair>, as are
+// its names in other locales, as for the track. There may be a name
+// for each locale, given with lang or xml:lang.
+type trackExtensions struct {
+	Color      string    `xml:"trk>extensions>color"`
+	TextColor  string    `xml:"trk>extensions>text_color"`
+	LineColor  string    `xml:"trk>extensions>line>color"`
+	Agency     string    `xml:"trk>extensions>agency"`
+	Fare       *gpxFare  `xml:"trk>extensions>fare"`
+	Wheelchair string    `xml:"trk>extensions>wheelchair"`
+	Names      []gpxName `xml:"trk>extensions>name"`
+	Wpt        []struct {
+		Type       string    `xml:"type"`
+		Zone       string    `xml:"extensions>zone"`
+		Wheelchair string    `xml:"extensions>wheelchair"`
+		Names      []gpxName `xml:"extensions>name"`
+	} `xml:"wpt"`
+}
+
+// A gpxName is a localized name in trackExtensions.
+type gpxName struct {
+	Lang string `xml:"lang,attr"`
+	Name string `xml:",chardata"`
+}
+
+// A gpxFare is the fare element of trackExtensions.
+type gpxFare struct {
+	Currency string `xml:"currency,attr"`
+	Flat     string `xml:"flat,attr"`
+	PerKm    string `xml:"per_km,attr"`
+	PerZone  string `xml:"per_zone,attr"`
+}
+
+// applyExtensions sets the fields of t given by the track extensions
+// in its GPX file src. Values which cannot be understood are ignored.
+func (t *track) applyExtensions(src []byte) {
+	var ext trackExtensions
+	if err := xml.Unmarshal(src, &ext); err != nil {
+		return
+	}
+	t.color = normalizeColor(ext.Color)
+	if t.color == "" {
+		t.color = normalizeColor(ext.LineColor)
+	}
+	t.textColor = normalizeColor(ext.TextColor)
+	t.agencyID = strings.TrimSpace(ext.Agency)
+	if ext.Fare != nil {
+		t.fare = ext.Fare.parse()
+	}
+	t.wheelchair = parseWheelchair(ext.Wheelchair)
+	t.names = localizedNames(ext.Names)
+	k := 0
+	for _, w := range ext.Wpt {
+		if isStopType(w.Type) && k < len(t.stops) {
+			t.stops[k].zone = strings.TrimSpace(w.Zone)
+			t.stops[k].wheelchair = parseWheelchair(w.Wheelchair)
+			t.stops[k].names = localizedNames(w.Names)
+			k++
+		}
+	}
+}
+
+// RouteExtensions returns the raw XML inside the <extensions> elements
+// of the GPX file route i was loaded from, for callers to extract
+// their own fields from, such as a fare zone or vehicle type. The
+// extensions of the gpx, metadata and trk elements are returned in
+// that order; those of single waypoints are not. It returns nil if
+// there are none, or if the route was not loaded from GPX.
+func (db *Db) RouteExtensions(i int) ([]byte, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	if t.src == nil {
+		return nil, nil
+	}
+	var ext gpxExtensions
+	if err := xml.Unmarshal(t.src, &ext); err != nil {
+		return nil, err
+	}
+	raw := bytes.Join([][]byte{
+		bytes.TrimSpace(ext.Gpx.Inner),
+		bytes.TrimSpace(ext.Metadata.Inner),
+		bytes.TrimSpace(ext.Trk.Inner),
+	}, nil)
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	return raw, nil
+}
@@ -0,0 +1,40 @@
+package routedb
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRouteExtensions(t *testing.T) {
+	in := []byte(`<gpx xmlns="http://www.topografix.com/GPX/1/1" xmlns:osh="http://example.com/osh">
+<metadata><name>kg-osh-149</name></metadata>
+<trk><extensions><osh:zone>2</osh:zone><osh:vehicle>minibus</osh:vehicle></extensions>
+<trkseg>
+<trkpt lat="40.5" lon="72.8"><extensions><osh:stop>yes</osh:stop></extensions></trkpt>
+<trkpt lat="40.51" lon="72.81"/>
+</trkseg></trk>
+</gpx>`)
+	edb, err := LoadAuto(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := edb.RouteExtensions(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(raw, []byte("<osh:zone>2</osh:zone>")) ||
+		!bytes.Contains(raw, []byte("<osh:vehicle>minibus</osh:vehicle>")) {
+		t.Errorf("extensions missing from %q", raw)
+	}
+	if bytes.Contains(raw, []byte("osh:stop")) {
+		t.Errorf("waypoint extensions included in %q", raw)
+	}
+
+	raw, err = db.RouteExtensions(0)
+	if err != nil || raw != nil {
+		t.Errorf("fixture extensions are %q, %v", raw, err)
+	}
+	if _, err := db.RouteExtensions(db.Routes()); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
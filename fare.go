@@ -0,0 +1,127 @@
+package routedb
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Fare is the price of a ride on a route. A ride costs Flat, plus
+// PerKm for each kilometre ridden, for fares by distance, plus PerZone
+// for each time the ride crosses into another fare zone, for fares by
+// zone. A flat fare has PerKm and PerZone zero.
+//
+// The fare of a route is given in the extensions of the track in its
+// GPX file, as described for trackExtensions, or in its metadata, as
+// described for routeMetadata, and the fare zones in the extensions of
+// its named stops.
+type Fare struct {
+	Currency string  `json:"currency"` // ISO 4217 code, such as "KGS"
+	Flat     float64 `json:"flat"`
+	PerKm    float64 `json:"per_km"`
+	PerZone  float64 `json:"per_zone"`
+}
+
+// parse returns the Fare f gives. Amounts which are not numbers are
+// taken to be zero.
+func (f *gpxFare) parse() *Fare {
+	num := func(s string) float64 {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return 0
+		}
+		return v
+	}
+	return &Fare{
+		Currency: strings.ToUpper(strings.TrimSpace(f.Currency)),
+		Flat:     num(f.Flat),
+		PerKm:    num(f.PerKm),
+		PerZone:  num(f.PerZone),
+	}
+}
+
+// errNoFare is the error returned for a route with no fare given.
+var errNoFare = errors.New("route has no fare")
+
+// Fare returns the fare of route i, or an error if it has none.
+func (db *Db) Fare(routeIndex int) (*Fare, error) {
+	t, err := db.routeAt(routeIndex)
+	if err != nil {
+		return nil, err
+	}
+	if t.fare == nil {
+		return nil, errNoFare
+	}
+	f := *t.fare
+	return &f, nil
+}
+
+// FareEstimate returns the price, in the currency of its Fare, of a
+// ride on route i boarding and alighting at the points on its path
+// nearest to the given ones. The distance ridden is measured along the
+// path, as by DistanceBetween, so on a circular route a ride whose
+// destination comes before its start goes on round the loop. The zone
+// the rider is in at a point is that of the last named stop with a zone
+// before it, or of the first such stop if there is none before it.
+func (db *Db) FareEstimate(routeIndex int, fromLat, fromLon, toLat, toLon float64) (float64, error) {
+	meters, err := db.DistanceBetween(routeIndex, fromLat, fromLon, toLat, toLon)
+	if err != nil {
+		return 0, err
+	}
+	t := db.routes[routeIndex]
+	if t.fare == nil {
+		return 0, errNoFare
+	}
+	f := t.fare
+	price := f.Flat + f.PerKm*meters/1000
+	if f.PerZone != 0 {
+		price += f.PerZone * float64(zonesCrossed(t, Stop{fromLat, fromLon}, Stop{toLat, toLon}))
+	}
+	return price, nil
+}
+
+// zonesCrossed returns how many times a ride on t between the points
+// on its path nearest to a and b crosses into another fare zone. On a
+// circular route, a ride from a to a b before it goes round the loop,
+// crossing from the zone of the last stop into that of the first.
+func zonesCrossed(t *track, a, b Stop) int {
+	var zoned []stopVisit
+	for k, s := range t.stops {
+		if s.zone != "" {
+			zoned = append(zoned, stopVisit{k, alongPath(t.pts, s.pt)})
+		}
+	}
+	if len(zoned) < 2 {
+		return 0
+	}
+	sort.Stable(byAlong(zoned))
+
+	// at returns the index in zoned of the stop whose zone the point
+	// meters along the path is in.
+	at := func(meters float64) int {
+		k := sort.Search(len(zoned), func(k int) bool { return zoned[k].along > meters }) - 1
+		if k < 0 {
+			k = 0
+		}
+		return k
+	}
+	lo, hi := alongPath(t.pts, a), alongPath(t.pts, b)
+	wrap := t.circular && hi < lo
+	if lo > hi && !wrap {
+		lo, hi = hi, lo
+	}
+	n := 0
+	for k, end := at(lo), at(hi); k != end || wrap; {
+		next := k + 1
+		if next == len(zoned) {
+			// Round the loop from the last stop to the first.
+			next, wrap = 0, false
+		}
+		if t.stops[zoned[next].stop].zone != t.stops[zoned[k].stop].zone {
+			n++
+		}
+		k = next
+	}
+	return n
+}
@@ -0,0 +1,123 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFare(t *testing.T) {
+	sdb, err := Load(zipGPX(t, `<gpx><metadata><name>kg-osh-1</name></metadata>
+<wpt lat="40.5" lon="72.8"><name>A</name><type>stop</type><extensions><zone>1</zone></extensions></wpt>
+<wpt lat="40.5" lon="72.81"><name>B</name><type>stop</type><extensions><zone>1</zone></extensions></wpt>
+<wpt lat="40.5" lon="72.805"><name>Not a stop</name><extensions><zone>9</zone></extensions></wpt>
+<wpt lat="40.5" lon="72.82"><name>C</name><type>stop</type><extensions><zone>2</zone></extensions></wpt>
+<wpt lat="40.5" lon="72.83"><name>D</name><type>stop</type><extensions><zone>3</zone></extensions></wpt>
+<trk><extensions><fare currency="kgs" flat="10" per_km="2" per_zone="5"/></extensions>
+<trkseg>
+<trkpt lat="40.5" lon="72.8"/>
+<trkpt lat="40.5" lon="72.83"/>
+</trkseg></trk></gpx>`, `<gpx><metadata><name>kg-osh-2</name></metadata>
+<trk><extensions><fare currency="KGS" flat="15"/></extensions>
+<trkseg><trkpt lat="40.5" lon="72.8"/><trkpt lat="40.5" lon="72.83"/></trkseg></trk></gpx>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := sdb.Fare(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *f != (Fare{"KGS", 10, 2, 5}) {
+		t.Errorf("fare is %+v", f)
+	}
+	if _, err := db.Fare(0); err != errNoFare {
+		t.Errorf("expected no fare, got %v", err)
+	}
+	if _, err := sdb.Fare(2); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+
+	km := distance(Stop{40.5, 72.8}, Stop{40.5, 72.81}) / 1000
+	for _, tc := range []struct {
+		route                          int
+		fromLat, fromLon, toLat, toLon float64
+		want                           float64
+	}{
+		// Within zone 1.
+		{0, 40.5, 72.8, 40.5, 72.81, 10 + 2*km},
+		// From zone 1 to zone 2, either way round.
+		{0, 40.5, 72.805, 40.5, 72.825, 10 + 2*2*km + 5},
+		{0, 40.5, 72.825, 40.5, 72.805, 10 + 2*2*km + 5},
+		// From zone 1 through zone 2 to zone 3.
+		{0, 40.5, 72.805, 40.5, 72.83, 10 + 2*2.5*km + 2*5},
+		// Into zone 2.
+		{0, 40.5, 72.815, 40.5, 72.82, 10 + 2*km/2 + 5},
+		// A flat fare.
+		{1, 40.5, 72.8, 40.5, 72.83, 15},
+	} {
+		p, err := sdb.FareEstimate(tc.route, tc.fromLat, tc.fromLon, tc.toLat, tc.toLon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(p-tc.want) > 0.01 {
+			t.Errorf("fare on %v from %v to %v is %v, expected %v", tc.route, tc.fromLon, tc.toLon, p, tc.want)
+		}
+	}
+	if _, err := db.FareEstimate(0, 40.5, 72.8, 40.5, 72.81); err != errNoFare {
+		t.Errorf("expected no fare, got %v", err)
+	}
+	if _, err := sdb.FareEstimate(0, 40.5, 272.8, 40.5, 72.81); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
+
+func TestFareEstimateCircular(t *testing.T) {
+	sdb, err := Load(zipGPX(t, `<gpx><metadata><name>kg-osh-1</name></metadata>
+<wpt lat="40.505" lon="72.8"><name>A</name><type>stop</type><extensions><zone>1</zone></extensions></wpt>
+<wpt lat="40.51" lon="72.805"><name>B</name><type>stop</type><extensions><zone>2</zone></extensions></wpt>
+<wpt lat="40.505" lon="72.81"><name>C</name><type>stop</type><extensions><zone>3</zone></extensions></wpt>
+<trk><extensions><fare currency="kgs" flat="10" per_km="2" per_zone="5"/></extensions>
+<trkseg>
+<trkpt lat="40.5" lon="72.8"/>
+<trkpt lat="40.51" lon="72.8"/>
+<trkpt lat="40.51" lon="72.81"/>
+<trkpt lat="40.5001" lon="72.81"/>
+<trkpt lat="40.5001" lon="72.8"/>
+</trkseg></trk></gpx>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := sdb.IsCircular(0); !c {
+		t.Fatal("route is not circular")
+	}
+
+	from, to := Stop{40.5001, 72.805}, Stop{40.5025, 72.8}
+	for _, tc := range []struct {
+		from, to Stop
+		zones    int
+	}{
+		// From zone 1 through zone 2 to zone 3.
+		{to, from, 2},
+		// From zone 3 round the loop into zone 1.
+		{from, to, 1},
+	} {
+		meters, err := sdb.DistanceBetween(0, tc.from.Lat, tc.from.Lon, tc.to.Lat, tc.to.Lon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := sdb.FareEstimate(0, tc.from.Lat, tc.from.Lon, tc.to.Lat, tc.to.Lon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := 10 + 2*meters/1000 + 5*float64(tc.zones); math.Abs(p-want) > 0.01 {
+			t.Errorf("fare from %v to %v is %v, expected %v", tc.from, tc.to, p, want)
+		}
+	}
+
+	// Round the loop is the short way here.
+	there, _ := sdb.DistanceBetween(0, to.Lat, to.Lon, from.Lat, from.Lon)
+	back, _ := sdb.DistanceBetween(0, from.Lat, from.Lon, to.Lat, to.Lon)
+	if back >= there {
+		t.Errorf("distance round the loop %v, expected less than %v", back, there)
+	}
+}
@@ -0,0 +1,80 @@
+package routedb
+
+import (
+	"math"
+	"sort"
+)
+
+// A Federation answers queries across several databases, such as one
+// per region of a country, consulting only those near the query.
+type Federation struct {
+	dbs []*Db
+}
+
+// NewFederation returns a Federation of dbs. The databases should not
+// be changed while the Federation is in use.
+func NewFederation(dbs ...*Db) *Federation {
+	return &Federation{dbs: append([]*Db{}, dbs...)}
+}
+
+// boxDistance returns the distance in meters from p to the nearest
+// point of b, or 0 if b contains p.
+func boxDistance(p Stop, b Box) float64 {
+	q := Stop{
+		Lat: math.Max(b.S, math.Min(b.N, p.Lat)),
+		Lon: math.Max(b.W, math.Min(b.E, p.Lon)),
+	}
+	return distance(p, q)
+}
+
+// byBoxDistance sorts databases by the distance of their bounds from
+// a point.
+type byBoxDistance struct {
+	dbs []*Db
+	d   []float64
+}
+
+func (s byBoxDistance) Len() int           { return len(s.dbs) }
+func (s byBoxDistance) Less(i, j int) bool { return s.d[i] < s.d[j] }
+func (s byBoxDistance) Swap(i, j int) {
+	s.dbs[i], s.dbs[j] = s.dbs[j], s.dbs[i]
+	s.d[i], s.d[j] = s.d[j], s.d[i]
+}
+
+// Nearest returns the stop nearest to the given point in any of the
+// databases, chosen as by Db.Nearest. They are consulted in order of the distance of their
+// bounds from the point, starting with any containing it, and the
+// search ends as soon as the rest are further away than the best stop
+// found.
+func (f *Federation) Nearest(lat, lon float64) (*Stop, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	p := Stop{lat, lon}
+	s := byBoxDistance{}
+	for _, db := range f.dbs {
+		if len(db.routes) == 0 {
+			continue
+		}
+		s.dbs = append(s.dbs, db)
+		s.d = append(s.d, boxDistance(p, db.bounds))
+	}
+	sort.Sort(s)
+
+	var best *Stop
+	d := math.Inf(1)
+	for k, db := range s.dbs {
+		if s.d[k] > d {
+			break
+		}
+		ri, k, dd := db.nearestBoarding(p)
+		if ri >= 0 && dd < d {
+			pt := db.routes[ri].boardingPoint(k)
+			best, d = &Stop{Lat: pt.Lat, Lon: pt.Lon}, dd
+		}
+	}
+	if best == nil {
+		return nil, errNoStop
+	}
+	return best, nil
+}
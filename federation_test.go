@@ -0,0 +1,34 @@
+package routedb
+
+import "testing"
+
+func TestFederation(t *testing.T) {
+	osh := makeDb(t, testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.51, 72.81}})
+	bishkek := makeDb(t, testRoute{"kg-bishkek-1", []float64{42.87, 74.59, 42.88, 74.60}})
+	f := NewFederation(osh, bishkek, &Db{})
+
+	for _, tc := range []struct {
+		lat, lon float64
+		want     Stop
+	}{
+		{40.505, 72.81, Stop{40.51, 72.81}},
+		{42.871, 74.59, Stop{42.87, 74.59}},
+		{45, 80, Stop{42.88, 74.60}},
+		{30, 60, Stop{40.50, 72.80}},
+	} {
+		s, err := f.Nearest(tc.lat, tc.lon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *s != tc.want {
+			t.Errorf("nearest to %v, %v is %v, expected %v", tc.lat, tc.lon, *s, tc.want)
+		}
+	}
+
+	if _, err := NewFederation().Nearest(40.5, 72.8); err != errNoStop {
+		t.Errorf("expected no stop error, got %v", err)
+	}
+	if _, err := f.Nearest(0, 181); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
@@ -0,0 +1,86 @@
+package routedb
+
+import (
+	"math"
+	"sort"
+)
+
+// A TraceCandidate is a route scored by how similar its path is to a
+// trace, as found by IdentifyTrace.
+type TraceCandidate struct {
+	RouteIndex int
+
+	// Frechet is the discrete Fréchet distance in meters between
+	// the trace and the route's path.
+	Frechet float64
+}
+
+// byFrechet sorts TraceCandidates most similar first.
+type byFrechet []TraceCandidate
+
+func (s byFrechet) Len() int           { return len(s) }
+func (s byFrechet) Less(i, j int) bool { return s[i].Frechet < s[j].Frechet }
+func (s byFrechet) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// frechet returns the discrete Fréchet distance in meters between the
+// paths a and b: the shortest leash with which a dog can walk one path
+// while its owner walks the other, both only forward, stepping from
+// point to point. Unlike the average distance between them, it is
+// large for paths running the other way, or only partly overlapping.
+func frechet(a, b []Stop) float64 {
+	// Dynamic programming over the points of a, keeping one row
+	// of the table at a time.
+	prev := make([]float64, len(b))
+	cur := make([]float64, len(b))
+	for i := range a {
+		for j := range b {
+			d := distance(a[i], b[j])
+			switch {
+			case i == 0 && j == 0:
+				cur[j] = d
+			case i == 0:
+				cur[j] = math.Max(cur[j-1], d)
+			case j == 0:
+				cur[j] = math.Max(prev[j], d)
+			default:
+				cur[j] = math.Max(math.Min(prev[j], math.Min(prev[j-1], cur[j-1])), d)
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)-1]
+}
+
+// IdentifyTrace compares a recorded trace, given by the parallel
+// slices lats and lons, with the path of every route, returning up to
+// limit routes most similar to it first. Similarity is measured by
+// the discrete Fréchet distance, so a route only scores well if the
+// trace follows it from end to end in the same direction. To keep the
+// work bounded, the trace and the paths are first resampled to points
+// every sampleMeters along them; 25 to 100 meters suits city routes.
+// The work grows with the square of the number of points, so it is an
+// error for sampleMeters to be less than minSpacingMeters. A limit of
+// 0 or less means no limit.
+func (db *Db) IdentifyTrace(lats, lons []float64, sampleMeters float64, limit int) ([]TraceCandidate, error) {
+	trace, err := traceStops(lats, lons)
+	if err != nil {
+		return nil, err
+	}
+	if !(sampleMeters >= minSpacingMeters) {
+		return nil, errSpacing
+	}
+	trace = resample(trace, sampleMeters)
+
+	cands := []TraceCandidate{}
+	for i, t := range db.routes {
+		if len(t.pts) == 0 {
+			continue
+		}
+		cands = append(cands, TraceCandidate{i, frechet(trace, resample(t.pts, sampleMeters))})
+	}
+	sort.Stable(byFrechet(cands))
+	if limit > 0 && len(cands) > limit {
+		cands = cands[:limit]
+	}
+	return cands, nil
+}
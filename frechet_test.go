@@ -0,0 +1,70 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFrechet(t *testing.T) {
+	a := []Stop{{40.5, 72.80}, {40.5, 72.81}, {40.5, 72.82}}
+	b := []Stop{{40.5001, 72.80}, {40.5001, 72.81}, {40.5001, 72.82}}
+	if d := frechet(a, b); math.Abs(d-distance(a[0], b[0])) > 0.01 {
+		t.Errorf("distance %v between parallel paths, expected %v", d, distance(a[0], b[0]))
+	}
+	// Being discrete, it measures between the points, not the
+	// segments.
+	c := []Stop{{40.5, 72.80}, {40.5, 72.805}, {40.5, 72.81}, {40.5, 72.82}}
+	if d, l := frechet(a, c), distance(a[0], c[1]); math.Abs(d-l) > 0.01 {
+		t.Errorf("distance %v to the same path with an extra point, expected %v", d, l)
+	}
+	if d := frechet(a, a); d != 0 {
+		t.Errorf("distance %v between a path and itself", d)
+	}
+	// Walking one path backwards needs a leash the whole length.
+	rev, _ := reversed(a, nil)
+	if d, l := frechet(a, rev), distance(a[0], a[2]); math.Abs(d-l) > 0.01 {
+		t.Errorf("distance %v to reversed path, expected %v", d, l)
+	}
+}
+
+func TestIdentifyTrace(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-east", []float64{40.5, 72.80, 40.5, 72.82}},
+		testRoute{"kg-osh-west", []float64{40.5, 72.82, 40.5, 72.80}},
+		// Shares the first half of the trace, then turns off.
+		testRoute{"kg-osh-turn", []float64{40.5, 72.80, 40.5, 72.81, 40.51, 72.81}},
+		testRoute{"kg-osh-far", []float64{40.6, 72.80, 40.6, 72.82}},
+	)
+	// A noisy trace heading east.
+	lats := []float64{40.50005, 40.49996, 40.50003, 40.49998, 40.50001}
+	lons := []float64{72.8001, 72.805, 72.81, 72.815, 72.8199}
+
+	cands, err := db.IdentifyTrace(lats, lons, 50, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 2, 1, 3}
+	if len(cands) != len(want) {
+		t.Fatalf("got %v candidates, expected %v", len(cands), len(want))
+	}
+	for k, c := range cands {
+		if c.RouteIndex != want[k] {
+			t.Errorf("candidate %v is route %v, expected %v", k, c.RouteIndex, want[k])
+		}
+	}
+	if cands[0].Frechet > 15 {
+		t.Errorf("best candidate at %v meters", cands[0].Frechet)
+	}
+
+	if cands, _ := db.IdentifyTrace(lats, lons, 50, 1); len(cands) != 1 {
+		t.Errorf("limit 1 gave %v candidates", len(cands))
+	}
+	for _, spacing := range []float64{0, 0.001, math.NaN()} {
+		if _, err := db.IdentifyTrace(lats, lons, spacing, 1); err == nil {
+			t.Errorf("expected error for spacing %v", spacing)
+		}
+	}
+	if _, err := db.IdentifyTrace(nil, nil, 50, 1); err == nil {
+		t.Error("expected error for empty trace")
+	}
+}
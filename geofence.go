@@ -0,0 +1,120 @@
+package routedb
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+// geofenceExit is how much further than its radius a position must
+// be from a stop for a Geofencer to consider it left, so that GPS
+// jitter at the edge does not ring the bell again and again.
+const geofenceExit = 1.2
+
+// A GeofenceHandler is told when a Geofencer's position comes near one
+// of its stops. Apps implement it, in Java too thanks to gobind.
+type GeofenceHandler interface {
+	// OnEnter is called when the position comes within the radius
+	// of the stop with the given ID, with the position's distance
+	// from it in meters.
+	OnEnter(stopID int, meters float64)
+}
+
+// A Geofencer watches a moving position, fed to it fix by fix, and
+// tells its handler when the position comes near one of a set of
+// stops, such as where the user wants to get off. It is safe for
+// concurrent use.
+type Geofencer struct {
+	radius  float64
+	handler GeofenceHandler
+
+	mu     sync.Mutex
+	stops  map[int]Stop
+	inside map[int]bool
+	nextID int
+}
+
+// NewGeofencer returns a Geofencer calling handler whenever the
+// position comes within radiusMeters of one of its stops. It calls
+// handler again for a stop only once the position has gone further
+// than 1.2 times radiusMeters from it.
+func NewGeofencer(radiusMeters float64, handler GeofenceHandler) *Geofencer {
+	return &Geofencer{
+		radius:  radiusMeters,
+		handler: handler,
+		stops:   make(map[int]Stop),
+		inside:  make(map[int]bool),
+	}
+}
+
+// AddStop adds a stop to watch for, returning its ID.
+func (g *Geofencer) AddStop(lat, lon float64) (int, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return -1, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	id := g.nextID
+	g.nextID++
+	g.stops[id] = Stop{lat, lon}
+	return id, nil
+}
+
+// RemoveStop stops watching for the stop with the given ID.
+func (g *Geofencer) RemoveStop(stopID int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	delete(g.stops, stopID)
+	delete(g.inside, stopID)
+}
+
+// A geofenceEntry is the position coming within the radius of a stop.
+type geofenceEntry struct {
+	id     int
+	meters float64
+}
+
+// byEntry sorts geofenceEntries nearest first, and then by stop ID.
+type byEntry []geofenceEntry
+
+func (s byEntry) Len() int { return len(s) }
+func (s byEntry) Less(i, j int) bool {
+	if s[i].meters != s[j].meters {
+		return s[i].meters < s[j].meters
+	}
+	return s[i].id < s[j].id
+}
+func (s byEntry) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// Update moves the position to a new fix, calling the handler for each
+// stop the position has come near, nearest first. The handler is
+// called after Update has finished with the Geofencer's state, so it
+// may call the Geofencer's methods itself.
+func (g *Geofencer) Update(lat, lon float64) error {
+	if err := checkLatLon(lat, lon); err != nil {
+		return err
+	}
+	if g.handler == nil {
+		return errors.New("no handler")
+	}
+	p := Stop{lat, lon}
+	var entered []geofenceEntry
+	g.mu.Lock()
+	for id, s := range g.stops {
+		d := distance(p, s)
+		switch {
+		case d <= g.radius && !g.inside[id]:
+			g.inside[id] = true
+			entered = append(entered, geofenceEntry{id, d})
+		case d > g.radius*geofenceExit:
+			delete(g.inside, id)
+		}
+	}
+	g.mu.Unlock()
+
+	sort.Sort(byEntry(entered))
+	for _, e := range entered {
+		g.handler.OnEnter(e.id, e.meters)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package routedb
+
+import "testing"
+
+// enterRecorder is a GeofenceHandler recording the stops entered.
+type enterRecorder struct {
+	ids []int
+}
+
+func (r *enterRecorder) OnEnter(stopID int, meters float64) {
+	r.ids = append(r.ids, stopID)
+}
+
+func TestGeofencer(t *testing.T) {
+	r := &enterRecorder{}
+	g := NewGeofencer(100, r)
+	a, _ := g.AddStop(40.5, 72.80)
+	b, _ := g.AddStop(40.5, 72.81)
+	c, _ := g.AddStop(40.5, 72.8105)
+	if _, err := g.AddStop(95, 72.8); err == nil {
+		t.Error("expected error for invalid latitude")
+	}
+
+	// Heading east past a and then reaching b and c together, c
+	// first as it is nearer, with jitter at the edge of a.
+	for _, lon := range []float64{72.795, 72.7995, 72.801, 72.7995, 72.8005, 72.802, 72.8103} {
+		if err := g.Update(40.5, lon); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []int{a, c, b}
+	if len(r.ids) != len(want) {
+		t.Fatalf("entered %v, expected %v", r.ids, want)
+	}
+	for k := range want {
+		if r.ids[k] != want[k] {
+			t.Errorf("entered %v, expected %v", r.ids, want)
+		}
+	}
+
+	// Leaving a properly and coming back rings again, unless it has
+	// been removed.
+	g.Update(40.5, 72.80)
+	if len(r.ids) != 4 || r.ids[3] != a {
+		t.Errorf("entered %v, expected a again", r.ids)
+	}
+	g.Update(40.51, 72.80)
+	g.RemoveStop(a)
+	g.Update(40.5, 72.80)
+	if len(r.ids) != 4 {
+		t.Errorf("entered %v after removing a", r.ids)
+	}
+
+	if err := NewGeofencer(100, nil).Update(40.5, 72.8); err == nil {
+		t.Error("expected error with no handler")
+	}
+}
@@ -0,0 +1,183 @@
+package routedb
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/kellydunn/golang-geo"
+)
+
+// distance returns the great circle distance in meters between a
+// and b.
+func distance(a, b Stop) float64 {
+	return geo.NewPoint(a.Lat, a.Lon).GreatCircleDistance(geo.NewPoint(b.Lat, b.Lon)) * 1000
+}
+
+// bearing returns the initial great circle bearing in degrees from a
+// to b, normalized to [0, 360).
+func bearing(a, b Stop) float64 {
+	d := geo.NewPoint(a.Lat, a.Lon).BearingTo(geo.NewPoint(b.Lat, b.Lon))
+	return math.Mod(d+360, 360)
+}
+
+// midpoint returns the point halfway along the great circle from a
+// to b.
+func midpoint(a, b Stop) Stop {
+	m := geo.NewPoint(a.Lat, a.Lon).MidpointTo(geo.NewPoint(b.Lat, b.Lon))
+	return Stop{m.Lat(), m.Lng()}
+}
+
+// checkLatLon returns an error if lat, lon is not a valid position,
+// such as the NaN reported by a GPS without a fix.
+func checkLatLon(lat, lon float64) error {
+	if math.IsNaN(lat) || math.IsInf(lat, 0) || lat < -90 || lat > 90 {
+		return fmt.Errorf("Invalid latitude %v", lat)
+	}
+	if math.IsNaN(lon) || math.IsInf(lon, 0) || lon < -180 || lon > 180 {
+		return fmt.Errorf("Invalid longitude %v", lon)
+	}
+	return nil
+}
+
+// pathLength returns the length in meters of the path through pts.
+func pathLength(pts []Stop) float64 {
+	l := 0.0
+	for j := 1; j < len(pts); j++ {
+		l += distance(pts[j-1], pts[j])
+	}
+	return l
+}
+
+// project returns the point on the segment from a to b closest to p,
+// and how far along the segment it is, from 0 at a to 1 at b. It
+// works on a plane tangent at p, which is accurate enough for the
+// short segments making up a route.
+func project(p, a, b Stop) (Stop, float64) {
+	k := math.Cos(p.Lat * math.Pi / 180)
+	ax, ay := (a.Lon-p.Lon)*k, a.Lat-p.Lat
+	dx, dy := (b.Lon-a.Lon)*k, b.Lat-a.Lat
+	t := 0.0
+	if l := dx*dx + dy*dy; l > 0 {
+		t = -(ax*dx + ay*dy) / l
+		t = math.Max(0, math.Min(1, t))
+	}
+	return Stop{a.Lat + t*(b.Lat-a.Lat), a.Lon + t*(b.Lon-a.Lon)}, t
+}
+
+// snap returns the point on the path through pts closest to p, the
+// index of the segment it lies on (segment j runs from pts[j] to
+// pts[j+1]), how far along that segment it is, and its distance in
+// meters from p. A path of one point has a single segment of zero
+// length. If pts is empty, seg is -1.
+func snap(p Stop, pts []Stop) (q Stop, seg int, t, d float64) {
+	seg, d = -1, math.Inf(1)
+	if len(pts) == 1 {
+		return pts[0], 0, 0, distance(p, pts[0])
+	}
+	for j := 0; j+1 < len(pts); j++ {
+		qq, tt := project(p, pts[j], pts[j+1])
+		if dd := distance(p, qq); dd < d {
+			q, seg, t, d = qq, j, tt, dd
+		}
+	}
+	return
+}
+
+// earthRadius is the radius in meters of the spherical earth on which
+// distance measures.
+const earthRadius = 6371000
+
+// metersPerDegree is the length in meters of a degree of latitude,
+// on the same spherical earth as distance.
+const metersPerDegree = earthRadius * math.Pi / 180
+
+// SlerpPoint returns the point a fraction t of the way from a to b
+// along the great circle between them, by spherical linear
+// interpolation. Unlike interpolating latitude and longitude
+// linearly, this stays on the shortest path between distant points.
+func SlerpPoint(a, b *Stop, t float64) *Stop {
+	va, vb := toVector(*a), toVector(*b)
+	cos := va[0]*vb[0] + va[1]*vb[1] + va[2]*vb[2]
+	omega := math.Acos(math.Max(-1, math.Min(1, cos)))
+	if omega < 1e-12 {
+		return &Stop{Lat: a.Lat + t*(b.Lat-a.Lat), Lon: a.Lon + t*(b.Lon-a.Lon)}
+	}
+	fa := math.Sin((1-t)*omega) / math.Sin(omega)
+	fb := math.Sin(t*omega) / math.Sin(omega)
+	s := fromVector([3]float64{
+		fa*va[0] + fb*vb[0],
+		fa*va[1] + fb*vb[1],
+		fa*va[2] + fb*vb[2],
+	})
+	return &s
+}
+
+// toVector returns the unit vector pointing from the center of the
+// earth to s.
+func toVector(s Stop) [3]float64 {
+	lat, lon := s.Lat*math.Pi/180, s.Lon*math.Pi/180
+	return [3]float64{math.Cos(lat) * math.Cos(lon), math.Cos(lat) * math.Sin(lon), math.Sin(lat)}
+}
+
+// fromVector returns the point on the surface of the earth in the
+// direction of v.
+func fromVector(v [3]float64) Stop {
+	return Stop{
+		Lat: math.Atan2(v[2], math.Hypot(v[0], v[1])) * 180 / math.Pi,
+		Lon: math.Atan2(v[1], v[0]) * 180 / math.Pi,
+	}
+}
+
+// slerpMeters is the length of segment beyond which interpolate
+// follows the great circle rather than a straight line in latitude
+// and longitude.
+const slerpMeters = 10000
+
+// interpolate returns the point a fraction f of the way from a to b.
+// Short segments, like those within a city, are interpolated
+// linearly, which is cheaper and indistinguishable; longer ones
+// follow the great circle.
+func interpolate(a, b Stop, f float64) Stop {
+	if distance(a, b) > slerpMeters {
+		return *SlerpPoint(&a, &b, f)
+	}
+	return Stop{Lat: a.Lat + f*(b.Lat-a.Lat), Lon: a.Lon + f*(b.Lon-a.Lon)}
+}
+
+// clipRange clips the segment from (x0, y0) to (x1, y1) to the
+// rectangle [xmin, xmax] x [ymin, ymax] using the Liang-Barsky
+// algorithm. It returns the part inside as the range of fractions
+// along the segment from t0 to t1, and false if none of it is inside.
+func clipRange(x0, y0, x1, y1, xmin, ymin, xmax, ymax float64) (t0, t1 float64, ok bool) {
+	t0, t1 = 0, 1
+	dx, dy := x1-x0, y1-y0
+	for _, c := range [][2]float64{
+		{-dx, x0 - xmin}, {dx, xmax - x0},
+		{-dy, y0 - ymin}, {dy, ymax - y0},
+	} {
+		p, q := c[0], c[1]
+		if p == 0 {
+			if q < 0 {
+				return 0, 0, false
+			}
+			continue
+		}
+		r := q / p
+		if p < 0 {
+			if r > t1 {
+				return 0, 0, false
+			}
+			if r > t0 {
+				t0 = r
+			}
+		} else {
+			if r < t0 {
+				return 0, 0, false
+			}
+			if r < t1 {
+				t1 = r
+			}
+		}
+	}
+	return t0, t1, true
+}
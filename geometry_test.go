@@ -0,0 +1,50 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+// offGreatCircle returns how far p is from the great circle through a
+// and b, in meters.
+func offGreatCircle(a, b, p Stop) float64 {
+	va, vb, vp := toVector(a), toVector(b), toVector(p)
+	n := [3]float64{
+		va[1]*vb[2] - va[2]*vb[1],
+		va[2]*vb[0] - va[0]*vb[2],
+		va[0]*vb[1] - va[1]*vb[0],
+	}
+	l := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])
+	sin := (n[0]*vp[0] + n[1]*vp[1] + n[2]*vp[2]) / l
+	return math.Abs(math.Asin(sin)) * 180 / math.Pi * metersPerDegree
+}
+
+func TestSlerpPoint(t *testing.T) {
+	// From Bishkek to Baku, about 2400 km east to west.
+	a, b := Stop{42.87, 74.59}, Stop{40.41, 49.87}
+
+	for _, f := range []float64{0, 0.25, 0.5, 0.75, 1} {
+		s := SlerpPoint(&a, &b, f)
+		if d := offGreatCircle(a, b, *s); d > 1 {
+			t.Errorf("slerp at %v is %v m off the great circle", f, d)
+		}
+		if d := distance(a, *s) - f*distance(a, b); math.Abs(d) > 1 {
+			t.Errorf("slerp at %v is %v m out along the great circle", f, d)
+		}
+	}
+
+	// Linear interpolation strays far from the great circle.
+	lin := Stop{(a.Lat + b.Lat) / 2, (a.Lon + b.Lon) / 2}
+	if d := offGreatCircle(a, b, lin); d < 10000 {
+		t.Errorf("linear midpoint is only %v m off the great circle", d)
+	}
+	if m := interpolate(a, b, 0.5); offGreatCircle(a, b, m) > 1 {
+		t.Errorf("interpolate did not follow the great circle on a long segment")
+	}
+
+	// Short segments are interpolated linearly.
+	c, d := Stop{40.5, 72.8}, Stop{40.51, 72.81}
+	if m := interpolate(c, d, 0.5); math.Abs(m.Lat-40.505) > 1e-9 || math.Abs(m.Lon-72.805) > 1e-9 {
+		t.Errorf("interpolated short segment to %v", m)
+	}
+}
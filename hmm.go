@@ -0,0 +1,167 @@
+package routedb
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+const (
+	// hmmSigma is the standard deviation in meters of the GPS error
+	// assumed by MatchTrace.
+	hmmSigma = 10
+
+	// hmmBeta is the scale in meters of the difference between how
+	// far a trace moves and how far along the route its matches
+	// move, beyond which MatchTrace finds a transition unlikely.
+	hmmBeta = 20
+
+	// hmmRadius is how far in meters from a fix MatchTrace looks
+	// for places on a route it might have been made at.
+	hmmRadius = 200
+
+	// hmmCandidates is the most places on a route MatchTrace
+	// considers for each fix, keeping the nearest.
+	hmmCandidates = 8
+)
+
+// An hmmCandidate is a place on a route where a fix might have been
+// made.
+type hmmCandidate struct {
+	pt    Stop
+	along float64 // meters from the start of the route
+	logp  float64 // log probability of the fix given this place
+}
+
+// byLogp sorts hmmCandidates most likely first.
+type byLogp []hmmCandidate
+
+func (s byLogp) Len() int           { return len(s) }
+func (s byLogp) Less(i, j int) bool { return s[i].logp > s[j].logp }
+func (s byLogp) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// MatchTrace finds the route on which the noisy GPS trace given by
+// the parallel slices lats and lons was most likely made, returning
+// its index and each fix snapped to where on the route it was made.
+//
+// Unlike MatchTraceScored, which looks at each fix on its own, this
+// follows the trace in order with a hidden Markov model, as described
+// by Newson and Krumm: each fix might have been made at any place on
+// the route within 200 meters of it, more likely the nearer it is, and
+// consecutive fixes more likely at places as far apart along the route
+// as the fixes are from each other. The Viterbi algorithm finds the
+// likeliest sequence of places on each route, and the route whose
+// sequence is likeliest wins. This copes with routes that pass the
+// same place twice, such as going out and back along a street, by
+// keeping the fixes moving forward along the route.
+//
+// A route is only considered if every fix lies within 200 meters of
+// it.
+func (db *Db) MatchTrace(lats, lons []float64) (routeIndex int, snapped []*Stop, err error) {
+	trace, err := traceStops(lats, lons)
+	if err != nil {
+		return -1, nil, err
+	}
+	routeIndex = -1
+	best := math.Inf(-1)
+	var path []Stop
+	for i, t := range db.routes {
+		if len(t.pts) == 0 {
+			continue
+		}
+		if logp, p := viterbi(trace, t.pts); p != nil && logp > best {
+			routeIndex, best, path = i, logp, p
+		}
+	}
+	if routeIndex < 0 {
+		return -1, nil, errors.New("no route matches the trace")
+	}
+	snapped = make([]*Stop, len(path))
+	for k, pt := range path {
+		snapped[k] = &Stop{Lat: pt.Lat, Lon: pt.Lon}
+	}
+	return routeIndex, snapped, nil
+}
+
+// hmmCandidatesOf returns the places on the path pts, whose waypoints
+// are at the distances along it in cum, where the fix p might have
+// been made: the nearest point of each segment within hmmRadius,
+// keeping at most hmmCandidates of them.
+func hmmCandidatesOf(p Stop, pts []Stop, cum []float64) []hmmCandidate {
+	var cs []hmmCandidate
+	add := func(q Stop, along float64) {
+		if d := distance(p, q); d <= hmmRadius {
+			cs = append(cs, hmmCandidate{q, along, -0.5 * (d / hmmSigma) * (d / hmmSigma)})
+		}
+	}
+	if len(pts) == 1 {
+		add(pts[0], 0)
+	}
+	for j := 0; j+1 < len(pts); j++ {
+		q, f := project(p, pts[j], pts[j+1])
+		add(q, cum[j]+f*(cum[j+1]-cum[j]))
+	}
+	sort.Stable(byLogp(cs))
+	if len(cs) > hmmCandidates {
+		cs = cs[:hmmCandidates]
+	}
+	return cs
+}
+
+// viterbi returns the log probability of the likeliest sequence of
+// places on the path pts at which the fixes of trace were made, and
+// that sequence. If a fix has no place on the path within hmmRadius,
+// the sequence is nil.
+func viterbi(trace []Stop, pts []Stop) (float64, []Stop) {
+	cum := cumulative(pts)
+
+	cands := make([][]hmmCandidate, len(trace))
+	for k, p := range trace {
+		if cands[k] = hmmCandidatesOf(p, pts, cum); len(cands[k]) == 0 {
+			return math.Inf(-1), nil
+		}
+	}
+
+	// score[c] is the log probability of the likeliest sequence
+	// ending at candidate c of the current fix, and back[k][c] the
+	// candidate of fix k-1 it came from.
+	score := make([]float64, len(cands[0]))
+	for c, cand := range cands[0] {
+		score[c] = cand.logp
+	}
+	back := make([][]int, len(trace))
+	for k := 1; k < len(trace); k++ {
+		moved := distance(trace[k-1], trace[k])
+		next := make([]float64, len(cands[k]))
+		back[k] = make([]int, len(cands[k]))
+		for c, cand := range cands[k] {
+			next[c] = math.Inf(-1)
+			for pc, prev := range cands[k-1] {
+				// Moving backwards along the route counts
+				// against the match twice over.
+				s := score[pc] - math.Abs(moved-(cand.along-prev.along))/hmmBeta
+				if s > next[c] {
+					next[c], back[k][c] = s, pc
+				}
+			}
+			next[c] += cand.logp
+		}
+		score = next
+	}
+
+	c := 0
+	for cc := range score {
+		if score[cc] > score[c] {
+			c = cc
+		}
+	}
+	best := score[c]
+	path := make([]Stop, len(trace))
+	for k := len(trace) - 1; k >= 0; k-- {
+		path[k] = cands[k][c].pt
+		if k > 0 {
+			c = back[k][c]
+		}
+	}
+	return best, path
+}
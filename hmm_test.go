@@ -0,0 +1,64 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatchTrace(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-elsewhere", []float64{40.51, 72.80, 40.51, 72.82}},
+		// Out east along a street, and back west on its other
+		// side, 20 meters further north.
+		testRoute{"kg-osh-outback", []float64{40.5, 72.80, 40.5, 72.82, 40.50018, 72.82, 40.50018, 72.80}},
+	)
+
+	// Heading west, a little nearer the eastbound side.
+	var lats, lons []float64
+	for lon := 72.815; lon > 72.8049; lon -= 0.0012 {
+		lats = append(lats, 40.50008)
+		lons = append(lons, lon)
+	}
+
+	// Looking at each fix on its own puts the trace on the wrong
+	// side of the street.
+	ri, _, naive, err := db.MatchTraceScored(lats, lons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ri != 1 || naive[0].Lat != 40.5 {
+		t.Fatalf("naive match on route %v at %v, test is not testing anything", ri, *naive[0])
+	}
+
+	ri, snapped, err := db.MatchTrace(lats, lons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ri != 1 {
+		t.Errorf("matched route %v, expected 1", ri)
+	}
+	if len(snapped) != len(lats) {
+		t.Fatalf("got %v snapped fixes, expected %v", len(snapped), len(lats))
+	}
+	for k, s := range snapped {
+		if math.Abs(s.Lat-40.50018) > 1e-9 || math.Abs(s.Lon-lons[k]) > 1e-6 {
+			t.Errorf("fix %v snapped to %v, expected the westbound side", k, *s)
+		}
+	}
+
+	// Heading east, the eastbound side wins.
+	for k := range lons {
+		lons[k] = 72.82 - (lons[k] - 72.80)
+	}
+	_, snapped, _ = db.MatchTrace(lats, lons)
+	if snapped[0].Lat != 40.5 {
+		t.Errorf("eastbound trace snapped to %v", *snapped[0])
+	}
+
+	if _, _, err := db.MatchTrace([]float64{41}, []float64{72.81}); err == nil {
+		t.Error("expected error for trace far from every route")
+	}
+	if _, _, err := db.MatchTrace(lats, lons[:1]); err == nil {
+		t.Error("expected error for mismatched slices")
+	}
+}
@@ -0,0 +1,49 @@
+package routedb
+
+import "fmt"
+
+// assignIDs gives an identifier to each route which has none. The
+// identifier is the route's metadata name, with a suffix "#2", "#3"
+// and so on when that is already taken by another route.
+func (db *Db) assignIDs() {
+	taken := make(map[string]bool)
+	for _, t := range db.routes {
+		if t.id != "" {
+			taken[t.id] = true
+		}
+	}
+	for _, t := range db.routes {
+		if t.id != "" {
+			continue
+		}
+		id := t.md
+		for n := 2; taken[id]; n++ {
+			id = fmt.Sprintf("%v#%v", t.md, n)
+		}
+		t.id = id
+		taken[id] = true
+	}
+}
+
+// RouteID returns the identifier of route i. Unlike its index, which
+// changes as other routes are added and removed, the identifier stays
+// with the route, so clients can keep it to find the route again with
+// IndexOfID. It is derived from the route's metadata name, with a
+// suffix to tell apart routes with the same name.
+func (db *Db) RouteID(i int) (string, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return "", err
+	}
+	return t.id, nil
+}
+
+// IndexOfID returns the index of the route with identifier id.
+func (db *Db) IndexOfID(id string) (int, bool) {
+	for i, t := range db.routes {
+		if t.id == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
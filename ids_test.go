@@ -0,0 +1,50 @@
+package routedb
+
+import "testing"
+
+func TestRouteID(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.8}},
+		testRoute{"kg-osh-2", []float64{40.51, 72.81}},
+		testRoute{"kg-osh-2", []float64{40.52, 72.82}},
+		testRoute{"kg-osh-3", []float64{40.53, 72.83}},
+	)
+
+	var ids []string
+	for i := 0; i < db.Routes(); i++ {
+		id, err := db.RouteID(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	exp := []string{"kg-osh-1", "kg-osh-2", "kg-osh-2#2", "kg-osh-3"}
+	for i := range exp {
+		if ids[i] != exp[i] {
+			t.Errorf("id %v is %q, expected %q", i, ids[i], exp[i])
+		}
+	}
+
+	db.removeRoute(1)
+	for _, tc := range []struct {
+		id  string
+		exp int
+	}{
+		{"kg-osh-1", 0},
+		{"kg-osh-2", -1},
+		{"kg-osh-2#2", 1},
+		{"kg-osh-3", 2},
+	} {
+		i, ok := db.IndexOfID(tc.id)
+		if i != tc.exp || ok != (tc.exp >= 0) {
+			t.Errorf("IndexOfID(%q) is %v, %v; expected %v", tc.id, i, ok, tc.exp)
+		}
+	}
+	if pt := db.routes[1].pts[0]; pt != (Stop{40.52, 72.82}) {
+		t.Errorf("kg-osh-2#2 resolved to the route starting at %v", pt)
+	}
+
+	if _, err := db.RouteID(3); err == nil {
+		t.Error("expected out of range error")
+	}
+}
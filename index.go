@@ -0,0 +1,250 @@
+package routedb
+
+import (
+	"math"
+	"sort"
+)
+
+// A kdTree is a spatial index of the waypoints of all the routes, used
+// to find the nearest waypoint to a point without measuring the
+// distance to every one.
+//
+// Waypoints are indexed as unit vectors from the centre of the earth,
+// so that the tree needs no special cases at the poles or the
+// antimeridian: the straight line distance between two such vectors
+// grows with the great circle distance between the points. The tree
+// is stored implicitly in nodes: the root of each range of nodes is
+// its middle one, splitting the rest by the coordinate on the axis for
+// its depth.
+type kdTree struct {
+	nodes []kdNode
+}
+
+// A kdNode is one waypoint in a kdTree.
+type kdNode struct {
+	v      [3]float64
+	pt     Stop
+	ri, pi int
+}
+
+// newKdTree returns an index of the points of routes picked by
+// points, such as their waypoints. The point index of each node is its
+// index in the slice returned by points.
+func newKdTree(routes []*track, points func(t *track) []Stop) *kdTree {
+	var nodes []kdNode
+	for i, t := range routes {
+		for j, pt := range points(t) {
+			nodes = append(nodes, kdNode{toVector(pt), pt, i, j})
+		}
+	}
+	kdBuild(nodes, 0)
+	return &kdTree{nodes: nodes}
+}
+
+// byAxis sorts kdNodes by one coordinate.
+type byAxis struct {
+	nodes []kdNode
+	axis  int
+}
+
+func (s byAxis) Len() int           { return len(s.nodes) }
+func (s byAxis) Less(i, j int) bool { return s.nodes[i].v[s.axis] < s.nodes[j].v[s.axis] }
+func (s byAxis) Swap(i, j int)      { s.nodes[i], s.nodes[j] = s.nodes[j], s.nodes[i] }
+
+// kdBuild arranges nodes into a tree whose root splits them on the
+// axis for depth.
+func kdBuild(nodes []kdNode, depth int) {
+	if len(nodes) <= 1 {
+		return
+	}
+	sort.Sort(byAxis{nodes, depth % 3})
+	m := len(nodes) / 2
+	kdBuild(nodes[:m], depth+1)
+	kdBuild(nodes[m+1:], depth+1)
+}
+
+// nearest returns the same as Db.nearestWhere, using the index, but
+// keep is given the point index as well as the route index of each
+// point. Ties are broken in favour of the lowest route and point
+// indices, as the scan in Db.nearestWhere does.
+func (k *kdTree) nearest(p Stop, keep func(ri, pi int) bool) (ri, pi int, d float64) {
+	s := kdSearch{p: p, v: toVector(p), keep: keep, ri: -1, pi: -1, d: math.Inf(1), chord: math.Inf(1)}
+	s.visit(k.nodes, 0)
+	return s.ri, s.pi, s.d
+}
+
+// nearestFrom is like nearest with no keep, but starts from the
+// point h of the tree, such as the answer for the previous point of a
+// trace.
+// If h is near p, most of the tree is pruned straight away.
+func (k *kdTree) nearestFrom(p Stop, h hit) (ri, pi int, d float64) {
+	s := kdSearch{p: p, v: toVector(p), ri: -1, pi: -1, d: math.Inf(1), chord: math.Inf(1)}
+	s.consider(h.pt, h.ri, h.pi)
+	s.visit(k.nodes, 0)
+	return s.ri, s.pi, s.d
+}
+
+// A kdSearch is the state of a search for the nearest waypoint.
+type kdSearch struct {
+	p    Stop
+	v    [3]float64
+	keep func(ri, pi int) bool
+
+	// The best waypoint so far, its distance in meters, and the
+	// straight line distance on the unit sphere beyond which no
+	// waypoint can beat it.
+	ri, pi int
+	d      float64
+	chord  float64
+}
+
+func (s *kdSearch) visit(nodes []kdNode, depth int) {
+	if len(nodes) == 0 {
+		return
+	}
+	m := len(nodes) / 2
+	n := &nodes[m]
+	if s.keep == nil || s.keep(n.ri, n.pi) {
+		s.consider(n.pt, n.ri, n.pi)
+	}
+
+	diff := s.v[depth%3] - n.v[depth%3]
+	near, far := nodes[:m], nodes[m+1:]
+	if diff >= 0 {
+		near, far = far, near
+	}
+	s.visit(near, depth+1)
+	if math.Abs(diff) <= s.chord {
+		s.visit(far, depth+1)
+	}
+}
+
+// A hit is a waypoint found by a search, with its distance in meters
+// from the point searched for.
+type hit struct {
+	pt     Stop
+	ri, pi int
+	d      float64
+}
+
+// before reports whether h ranks before o: nearer, or at the same
+// distance but with lower route and point indices.
+func (h hit) before(o hit) bool {
+	if h.d != o.d {
+		return h.d < o.d
+	}
+	if h.ri != o.ri {
+		return h.ri < o.ri
+	}
+	return h.pi < o.pi
+}
+
+// consider makes waypoint pi of route ri, at pt, the best so far if it
+// ranks before it.
+func (s *kdSearch) consider(pt Stop, ri, pi int) {
+	d := distance(s.p, pt)
+	if d < s.d || d == s.d && (ri < s.ri || ri == s.ri && pi < s.pi) {
+		s.ri, s.pi, s.d = ri, pi, d
+		// Allow for rounding, so as never to prune a waypoint at
+		// the same distance.
+		s.chord = 2*math.Sin(d/(2*earthRadius)) + 1e-9
+	}
+}
+
+// nearestN returns the same as Db.nearestN, using the index.
+func (k *kdTree) nearestN(p Stop, n int) []hit {
+	s := kdSearchN{p: p, v: toVector(p), n: n, chord: math.Inf(1)}
+	s.visit(k.nodes, 0)
+	return s.hits
+}
+
+// A kdSearchN is the state of a search for the n nearest distinct
+// waypoints.
+type kdSearchN struct {
+	p Stop
+	v [3]float64
+	n int
+
+	// The best hits so far, in rank order, and the straight line
+	// distance on the unit sphere beyond which no waypoint can join
+	// them.
+	hits  []hit
+	chord float64
+}
+
+func (s *kdSearchN) visit(nodes []kdNode, depth int) {
+	if len(nodes) == 0 {
+		return
+	}
+	m := len(nodes) / 2
+	n := &nodes[m]
+	s.add(hit{n.pt, n.ri, n.pi, distance(s.p, n.pt)})
+
+	diff := s.v[depth%3] - n.v[depth%3]
+	near, far := nodes[:m], nodes[m+1:]
+	if diff >= 0 {
+		near, far = far, near
+	}
+	s.visit(near, depth+1)
+	if math.Abs(diff) <= s.chord {
+		s.visit(far, depth+1)
+	}
+}
+
+// add adds h to the hits if it ranks among the best n. A waypoint at
+// the same place as one of the hits replaces it if it ranks before it,
+// and is otherwise dropped.
+func (s *kdSearchN) add(h hit) {
+	for k, o := range s.hits {
+		if o.pt == h.pt {
+			if !h.before(o) {
+				return
+			}
+			s.hits = append(s.hits[:k], s.hits[k+1:]...)
+			break
+		}
+	}
+	if len(s.hits) == s.n && !h.before(s.hits[s.n-1]) {
+		return
+	}
+	k := sort.Search(len(s.hits), func(k int) bool { return h.before(s.hits[k]) })
+	s.hits = append(s.hits, hit{})
+	copy(s.hits[k+1:], s.hits[k:])
+	s.hits[k] = h
+	if len(s.hits) > s.n {
+		s.hits = s.hits[:s.n]
+	}
+	if len(s.hits) == s.n {
+		// Allow for rounding, so as never to prune a waypoint at
+		// the same distance as the last hit.
+		s.chord = 2*math.Sin(s.hits[s.n-1].d/(2*earthRadius)) + 1e-9
+	}
+}
+
+// within returns the waypoints within meters of p, in no particular
+// order.
+func (k *kdTree) within(p Stop, meters float64) []hit {
+	var hits []hit
+	v := toVector(p)
+	chord := 2*math.Sin(math.Min(meters/(2*earthRadius), math.Pi/2)) + 1e-9
+	var visit func(nodes []kdNode, depth int)
+	visit = func(nodes []kdNode, depth int) {
+		if len(nodes) == 0 {
+			return
+		}
+		m := len(nodes) / 2
+		n := &nodes[m]
+		if d := distance(p, n.pt); d <= meters {
+			hits = append(hits, hit{n.pt, n.ri, n.pi, d})
+		}
+		diff := v[depth%3] - n.v[depth%3]
+		if diff <= chord {
+			visit(nodes[:m], depth+1)
+		}
+		if -diff <= chord {
+			visit(nodes[m+1:], depth+1)
+		}
+	}
+	visit(k.nodes, 0)
+	return hits
+}
@@ -0,0 +1,159 @@
+package routedb
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// checkIndex checks that the spatial index of db finds the same
+// nearest waypoints as a scan, for n random points within deg degrees
+// of the centre of db.
+func checkIndex(t *testing.T, db *Db, n int, deg float64) {
+	if db.index == nil {
+		t.Fatal("no index")
+	}
+	r := rand.New(rand.NewSource(1))
+	b := db.Bounds()
+	lat, lon := (b.N+b.S)/2, (b.E+b.W)/2
+	odd := func(i int) bool { return i%2 == 1 }
+	for k := 0; k < n; k++ {
+		p := Stop{lat + (r.Float64()*2-1)*deg, lon + (r.Float64()*2-1)*deg}
+		if p.Lat > 90 || p.Lat < -90 || p.Lon > 180 || p.Lon < -180 {
+			continue
+		}
+		for _, keep := range []func(int) bool{nil, odd} {
+			ri, pi, d := db.nearestWhere(p, keep)
+			sri, spi, sd := db.nearestScan(p, keep)
+			if ri != sri || pi != spi || d != sd {
+				t.Fatalf("nearest to %v is %v/%v at %v, scan found %v/%v at %v", p, ri, pi, d, sri, spi, sd)
+			}
+		}
+	}
+}
+
+func TestIndex(t *testing.T) {
+	checkIndex(t, db, 1000, 0.05)
+	checkIndex(t, db, 100, 50)
+	checkIndex(t, loadTestdata(t, "testdata/cities.zip"), 1000, 2)
+	checkIndex(t, loadTestdata(t, "testdata/antimeridian.zip"), 1000, 1)
+
+	// Routes at the poles and on both sides of the antimeridian,
+	// and two routes sharing a waypoint, which the scan breaks ties
+	// between by index.
+	pdb := makeDb(t,
+		testRoute{"aq-south-1", []float64{-89.99, 0, -89.99, 120, -89.99, -120}},
+		testRoute{"ru-north-1", []float64{89.9, 10, 89.9, -170}},
+		testRoute{"fj-east-1", []float64{-16.8, 179.99, -16.9, -179.99}},
+		testRoute{"fj-east-2", []float64{-16.9, -179.99, -16.8, 179.99}},
+	)
+	for _, p := range []Stop{{-90, 0}, {90, 0}, {89.9, 170}, {-16.85, 180}, {-16.85, -180}, {-16.9, -179.99}} {
+		ri, pi, d := pdb.nearest(p)
+		sri, spi, sd := pdb.nearestScan(p, nil)
+		if ri != sri || pi != spi || d != sd {
+			t.Errorf("nearest to %v is %v/%v at %v, scan found %v/%v at %v", p, ri, pi, d, sri, spi, sd)
+		}
+	}
+}
+
+func TestNoSpatialIndex(t *testing.T) {
+	ndb, err := LoadWithOptions(mustRead(t, "testdata/routedb.zip"), &LoadOptions{NoSpatialIndex: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ndb.index != nil {
+		t.Error("index built despite NoSpatialIndex")
+	}
+	s, err := ndb.Nearest(40.50263, 72.821976)
+	if err != nil || *s != (Stop{40.50263, 72.821976}) {
+		t.Errorf("nearest is %v, %v", s, err)
+	}
+	ndb.RemoveColinear(1)
+	if ndb.index != nil {
+		t.Error("index built when routes changed")
+	}
+}
+
+func TestIndexRebuilt(t *testing.T) {
+	rdb := loadTestdata(t, "testdata/routedb.zip")
+	rdb.RemoveColinear(5)
+	if len(rdb.index.nodes) != rdb.TotalWaypoints() {
+		t.Errorf("index has %v waypoints, expected %v", len(rdb.index.nodes), rdb.TotalWaypoints())
+	}
+	checkIndex(t, rdb, 1000, 0.05)
+}
+
+func BenchmarkNearest(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		db.Nearest(40.52, 72.81)
+	}
+}
+
+func BenchmarkNearestScan(b *testing.B) {
+	p := Stop{40.52, 72.81}
+	for i := 0; i < b.N; i++ {
+		db.nearestScan(p, nil)
+	}
+}
+
+func TestIndexNearestN(t *testing.T) {
+	cdb := loadTestdata(t, "testdata/cities.zip")
+	r := rand.New(rand.NewSource(1))
+	for _, db := range []*Db{db, cdb} {
+		b := db.Bounds()
+		for k := 0; k < 200; k++ {
+			p := Stop{b.S + r.Float64()*(b.N-b.S), b.W + r.Float64()*(b.E-b.W)}
+			n := 1 + r.Intn(20)
+			hits := db.index.nearestN(p, n)
+			index := db.index
+			db.index = nil
+			scan := db.nearestN(p, n)
+			db.index = index
+			if len(hits) != len(scan) {
+				t.Fatalf("%v nearest to %v: index found %v, scan %v", n, p, len(hits), len(scan))
+			}
+			for j := range hits {
+				if hits[j] != scan[j] {
+					t.Fatalf("%v nearest to %v: hit %v is %v, scan found %v", n, p, j, hits[j], scan[j])
+				}
+			}
+		}
+	}
+}
+
+func TestIndexWithin(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := db.Bounds()
+	for k := 0; k < 200; k++ {
+		p := Stop{b.S + r.Float64()*(b.N-b.S), b.W + r.Float64()*(b.E-b.W)}
+		meters := r.Float64() * 1000
+		hits := db.within(p, meters)
+		index := db.index
+		db.index = nil
+		scan := db.within(p, meters)
+		db.index = index
+		if len(hits) != len(scan) {
+			t.Fatalf("within %v of %v: index found %v, scan %v", meters, p, len(hits), len(scan))
+		}
+		for j := range hits {
+			if hits[j] != scan[j] {
+				t.Fatalf("within %v of %v: hit %v is %v, scan found %v", meters, p, j, hits[j], scan[j])
+			}
+		}
+	}
+}
+
+func TestIndexNearestFrom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	b := db.Bounds()
+	for k := 0; k < 200; k++ {
+		p := Stop{b.S + r.Float64()*(b.N-b.S), b.W + r.Float64()*(b.E-b.W)}
+		ri := r.Intn(len(db.routes))
+		pi := r.Intn(len(db.routes[ri].pts))
+		from := hit{db.routes[ri].pts[pi], ri, pi, 0}
+		fri, fpi, fd := db.index.nearestFrom(p, from)
+		sri, spi, sd := db.nearestScan(p, nil)
+		if fri != sri || fpi != spi || fd != sd {
+			t.Fatalf("nearest to %v from %v is %v/%v at %v, scan found %v/%v at %v", p, from, fri, fpi, fd, sri, spi, sd)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package routedb
+
+// countries lists the countries of ISO 3166-1, with their English
+// names, as in the iso-codes package.
+var countries = []country{
+	{"ad", "and", "Andorra", []string{"Principality of Andorra"}},
+	{"ae", "are", "United Arab Emirates", nil},
+	{"af", "afg", "Afghanistan", []string{"Islamic Republic of Afghanistan"}},
+	{"ag", "atg", "Antigua and Barbuda", nil},
+	{"ai", "aia", "Anguilla", nil},
+	{"al", "alb", "Albania", []string{"Republic of Albania"}},
+	{"am", "arm", "Armenia", []string{"Republic of Armenia"}},
+	{"ao", "ago", "Angola", []string{"Republic of Angola"}},
+	{"aq", "ata", "Antarctica", nil},
+	{"ar", "arg", "Argentina", []string{"Argentine Republic"}},
+	{"as", "asm", "American Samoa", nil},
+	{"at", "aut", "Austria", []string{"Republic of Austria"}},
+	{"au", "aus", "Australia", nil},
+	{"aw", "abw", "Aruba", nil},
+	{"ax", "ala", "Åland Islands", nil},
+	{"az", "aze", "Azerbaijan", []string{"Republic of Azerbaijan"}},
+	{"ba", "bih", "Bosnia and Herzegovina", []string{"Republic of Bosnia and Herzegovina"}},
+	{"bb", "brb", "Barbados", nil},
+	{"bd", "bgd", "Bangladesh", []string{"People's Republic of Bangladesh"}},
+	{"be", "bel", "Belgium", []string{"Kingdom of Belgium"}},
+	{"bf", "bfa", "Burkina Faso", nil},
+	{"bg", "bgr", "Bulgaria", []string{"Republic of Bulgaria"}},
+	{"bh", "bhr", "Bahrain", []string{"Kingdom of Bahrain"}},
+	{"bi", "bdi", "Burundi", []string{"Republic of Burundi"}},
+	{"bj", "ben", "Benin", []string{"Republic of Benin"}},
+	{"bl", "blm", "Saint Barthélemy", nil},
+	{"bm", "bmu", "Bermuda", nil},
+	{"bn", "brn", "Brunei Darussalam", nil},
+	{"bo", "bol", "Bolivia", []string{"Bolivia, Plurinational State of", "Plurinational State of Bolivia"}},
+	{"bq", "bes", "Bonaire, Sint Eustatius and Saba", nil},
+	{"br", "bra", "Brazil", []string{"Federative Republic of Brazil"}},
+	{"bs", "bhs", "Bahamas", []string{"Commonwealth of the Bahamas"}},
+	{"bt", "btn", "Bhutan", []string{"Kingdom of Bhutan"}},
+	{"bv", "bvt", "Bouvet Island", nil},
+	{"bw", "bwa", "Botswana", []string{"Republic of Botswana"}},
+	{"by", "blr", "Belarus", []string{"Republic of Belarus"}},
+	{"bz", "blz", "Belize", nil},
+	{"ca", "can", "Canada", nil},
+	{"cc", "cck", "Cocos (Keeling) Islands", nil},
+	{"cd", "cod", "Congo, The Democratic Republic of the", nil},
+	{"cf", "caf", "Central African Republic", nil},
+	{"cg", "cog", "Congo", []string{"Republic of the Congo"}},
+	{"ch", "che", "Switzerland", []string{"Swiss Confederation"}},
+	{"ci", "civ", "Côte d'Ivoire", []string{"Republic of Côte d'Ivoire"}},
+	{"ck", "cok", "Cook Islands", nil},
+	{"cl", "chl", "Chile", []string{"Republic of Chile"}},
+	{"cm", "cmr", "Cameroon", []string{"Republic of Cameroon"}},
+	{"cn", "chn", "China", []string{"People's Republic of China"}},
+	{"co", "col", "Colombia", []string{"Republic of Colombia"}},
+	{"cr", "cri", "Costa Rica", []string{"Republic of Costa Rica"}},
+	{"cu", "cub", "Cuba", []string{"Republic of Cuba"}},
+	{"cv", "cpv", "Cabo Verde", []string{"Republic of Cabo Verde"}},
+	{"cw", "cuw", "Curaçao", nil},
+	{"cx", "cxr", "Christmas Island", nil},
+	{"cy", "cyp", "Cyprus", []string{"Republic of Cyprus"}},
+	{"cz", "cze", "Czechia", []string{"Czech Republic"}},
+	{"de", "deu", "Germany", []string{"Federal Republic of Germany"}},
+	{"dj", "dji", "Djibouti", []string{"Republic of Djibouti"}},
+	{"dk", "dnk", "Denmark", []string{"Kingdom of Denmark"}},
+	{"dm", "dma", "Dominica", []string{"Commonwealth of Dominica"}},
+	{"do", "dom", "Dominican Republic", nil},
+	{"dz", "dza", "Algeria", []string{"People's Democratic Republic of Algeria"}},
+	{"ec", "ecu", "Ecuador", []string{"Republic of Ecuador"}},
+	{"ee", "est", "Estonia", []string{"Republic of Estonia"}},
+	{"eg", "egy", "Egypt", []string{"Arab Republic of Egypt"}},
+	{"eh", "esh", "Western Sahara", nil},
+	{"er", "eri", "Eritrea", []string{"the State of Eritrea"}},
+	{"es", "esp", "Spain", []string{"Kingdom of Spain"}},
+	{"et", "eth", "Ethiopia", []string{"Federal Democratic Republic of Ethiopia"}},
+	{"fi", "fin", "Finland", []string{"Republic of Finland"}},
+	{"fj", "fji", "Fiji", []string{"Republic of Fiji"}},
+	{"fk", "flk", "Falkland Islands (Malvinas)", nil},
+	{"fm", "fsm", "Micronesia, Federated States of", []string{"Federated States of Micronesia"}},
+	{"fo", "fro", "Faroe Islands", nil},
+	{"fr", "fra", "France", []string{"French Republic"}},
+	{"ga", "gab", "Gabon", []string{"Gabonese Republic"}},
+	{"gb", "gbr", "United Kingdom", []string{"United Kingdom of Great Britain and Northern Ireland"}},
+	{"gd", "grd", "Grenada", nil},
+	{"ge", "geo", "Georgia", nil},
+	{"gf", "guf", "French Guiana", nil},
+	{"gg", "ggy", "Guernsey", nil},
+	{"gh", "gha", "Ghana", []string{"Republic of Ghana"}},
+	{"gi", "gib", "Gibraltar", nil},
+	{"gl", "grl", "Greenland", nil},
+	{"gm", "gmb", "Gambia", []string{"Republic of the Gambia"}},
+	{"gn", "gin", "Guinea", []string{"Republic of Guinea"}},
+	{"gp", "glp", "Guadeloupe", nil},
+	{"gq", "gnq", "Equatorial Guinea", []string{"Republic of Equatorial Guinea"}},
+	{"gr", "grc", "Greece", []string{"Hellenic Republic"}},
+	{"gs", "sgs", "South Georgia and the South Sandwich Islands", nil},
+	{"gt", "gtm", "Guatemala", []string{"Republic of Guatemala"}},
+	{"gu", "gum", "Guam", nil},
+	{"gw", "gnb", "Guinea-Bissau", []string{"Republic of Guinea-Bissau"}},
+	{"gy", "guy", "Guyana", []string{"Republic of Guyana"}},
+	{"hk", "hkg", "Hong Kong", []string{"Hong Kong Special Administrative Region of China"}},
+	{"hm", "hmd", "Heard Island and McDonald Islands", nil},
+	{"hn", "hnd", "Honduras", []string{"Republic of Honduras"}},
+	{"hr", "hrv", "Croatia", []string{"Republic of Croatia"}},
+	{"ht", "hti", "Haiti", []string{"Republic of Haiti"}},
+	{"hu", "hun", "Hungary", nil},
+	{"id", "idn", "Indonesia", []string{"Republic of Indonesia"}},
+	{"ie", "irl", "Ireland", nil},
+	{"il", "isr", "Israel", []string{"State of Israel"}},
+	{"im", "imn", "Isle of Man", nil},
+	{"in", "ind", "India", []string{"Republic of India"}},
+	{"io", "iot", "British Indian Ocean Territory", nil},
+	{"iq", "irq", "Iraq", []string{"Republic of Iraq"}},
+	{"ir", "irn", "Iran", []string{"Iran, Islamic Republic of", "Islamic Republic of Iran"}},
+	{"is", "isl", "Iceland", []string{"Republic of Iceland"}},
+	{"it", "ita", "Italy", []string{"Italian Republic"}},
+	{"je", "jey", "Jersey", nil},
+	{"jm", "jam", "Jamaica", nil},
+	{"jo", "jor", "Jordan", []string{"Hashemite Kingdom of Jordan"}},
+	{"jp", "jpn", "Japan", nil},
+	{"ke", "ken", "Kenya", []string{"Republic of Kenya"}},
+	{"kg", "kgz", "Kyrgyzstan", []string{"Kyrgyz Republic"}},
+	{"kh", "khm", "Cambodia", []string{"Kingdom of Cambodia"}},
+	{"ki", "kir", "Kiribati", []string{"Republic of Kiribati"}},
+	{"km", "com", "Comoros", []string{"Union of the Comoros"}},
+	{"kn", "kna", "Saint Kitts and Nevis", nil},
+	{"kp", "prk", "North Korea", []string{"Korea, Democratic People's Republic of", "Democratic People's Republic of Korea"}},
+	{"kr", "kor", "South Korea", []string{"Korea, Republic of"}},
+	{"kw", "kwt", "Kuwait", []string{"State of Kuwait"}},
+	{"ky", "cym", "Cayman Islands", nil},
+	{"kz", "kaz", "Kazakhstan", []string{"Republic of Kazakhstan"}},
+	{"la", "lao", "Laos", []string{"Lao People's Democratic Republic"}},
+	{"lb", "lbn", "Lebanon", []string{"Lebanese Republic"}},
+	{"lc", "lca", "Saint Lucia", nil},
+	{"li", "lie", "Liechtenstein", []string{"Principality of Liechtenstein"}},
+	{"lk", "lka", "Sri Lanka", []string{"Democratic Socialist Republic of Sri Lanka"}},
+	{"lr", "lbr", "Liberia", []string{"Republic of Liberia"}},
+	{"ls", "lso", "Lesotho", []string{"Kingdom of Lesotho"}},
+	{"lt", "ltu", "Lithuania", []string{"Republic of Lithuania"}},
+	{"lu", "lux", "Luxembourg", []string{"Grand Duchy of Luxembourg"}},
+	{"lv", "lva", "Latvia", []string{"Republic of Latvia"}},
+	{"ly", "lby", "Libya", nil},
+	{"ma", "mar", "Morocco", []string{"Kingdom of Morocco"}},
+	{"mc", "mco", "Monaco", []string{"Principality of Monaco"}},
+	{"md", "mda", "Moldova", []string{"Moldova, Republic of", "Republic of Moldova"}},
+	{"me", "mne", "Montenegro", nil},
+	{"mf", "maf", "Saint Martin (French part)", nil},
+	{"mg", "mdg", "Madagascar", []string{"Republic of Madagascar"}},
+	{"mh", "mhl", "Marshall Islands", []string{"Republic of the Marshall Islands"}},
+	{"mk", "mkd", "North Macedonia", []string{"Republic of North Macedonia"}},
+	{"ml", "mli", "Mali", []string{"Republic of Mali"}},
+	{"mm", "mmr", "Myanmar", []string{"Republic of Myanmar"}},
+	{"mn", "mng", "Mongolia", nil},
+	{"mo", "mac", "Macao", []string{"Macao Special Administrative Region of China"}},
+	{"mp", "mnp", "Northern Mariana Islands", []string{"Commonwealth of the Northern Mariana Islands"}},
+	{"mq", "mtq", "Martinique", nil},
+	{"mr", "mrt", "Mauritania", []string{"Islamic Republic of Mauritania"}},
+	{"ms", "msr", "Montserrat", nil},
+	{"mt", "mlt", "Malta", []string{"Republic of Malta"}},
+	{"mu", "mus", "Mauritius", []string{"Republic of Mauritius"}},
+	{"mv", "mdv", "Maldives", []string{"Republic of Maldives"}},
+	{"mw", "mwi", "Malawi", []string{"Republic of Malawi"}},
+	{"mx", "mex", "Mexico", []string{"United Mexican States"}},
+	{"my", "mys", "Malaysia", nil},
+	{"mz", "moz", "Mozambique", []string{"Republic of Mozambique"}},
+	{"na", "nam", "Namibia", []string{"Republic of Namibia"}},
+	{"nc", "ncl", "New Caledonia", nil},
+	{"ne", "ner", "Niger", []string{"Republic of the Niger"}},
+	{"nf", "nfk", "Norfolk Island", nil},
+	{"ng", "nga", "Nigeria", []string{"Federal Republic of Nigeria"}},
+	{"ni", "nic", "Nicaragua", []string{"Republic of Nicaragua"}},
+	{"nl", "nld", "Netherlands", []string{"Kingdom of the Netherlands"}},
+	{"no", "nor", "Norway", []string{"Kingdom of Norway"}},
+	{"np", "npl", "Nepal", []string{"Federal Democratic Republic of Nepal"}},
+	{"nr", "nru", "Nauru", []string{"Republic of Nauru"}},
+	{"nu", "niu", "Niue", nil},
+	{"nz", "nzl", "New Zealand", nil},
+	{"om", "omn", "Oman", []string{"Sultanate of Oman"}},
+	{"pa", "pan", "Panama", []string{"Republic of Panama"}},
+	{"pe", "per", "Peru", []string{"Republic of Peru"}},
+	{"pf", "pyf", "French Polynesia", nil},
+	{"pg", "png", "Papua New Guinea", []string{"Independent State of Papua New Guinea"}},
+	{"ph", "phl", "Philippines", []string{"Republic of the Philippines"}},
+	{"pk", "pak", "Pakistan", []string{"Islamic Republic of Pakistan"}},
+	{"pl", "pol", "Poland", []string{"Republic of Poland"}},
+	{"pm", "spm", "Saint Pierre and Miquelon", nil},
+	{"pn", "pcn", "Pitcairn", nil},
+	{"pr", "pri", "Puerto Rico", nil},
+	{"ps", "pse", "Palestine, State of", []string{"the State of Palestine"}},
+	{"pt", "prt", "Portugal", []string{"Portuguese Republic"}},
+	{"pw", "plw", "Palau", []string{"Republic of Palau"}},
+	{"py", "pry", "Paraguay", []string{"Republic of Paraguay"}},
+	{"qa", "qat", "Qatar", []string{"State of Qatar"}},
+	{"re", "reu", "Réunion", nil},
+	{"ro", "rou", "Romania", nil},
+	{"rs", "srb", "Serbia", []string{"Republic of Serbia"}},
+	{"ru", "rus", "Russian Federation", nil},
+	{"rw", "rwa", "Rwanda", []string{"Rwandese Republic"}},
+	{"sa", "sau", "Saudi Arabia", []string{"Kingdom of Saudi Arabia"}},
+	{"sb", "slb", "Solomon Islands", nil},
+	{"sc", "syc", "Seychelles", []string{"Republic of Seychelles"}},
+	{"sd", "sdn", "Sudan", []string{"Republic of the Sudan"}},
+	{"se", "swe", "Sweden", []string{"Kingdom of Sweden"}},
+	{"sg", "sgp", "Singapore", []string{"Republic of Singapore"}},
+	{"sh", "shn", "Saint Helena, Ascension and Tristan da Cunha", nil},
+	{"si", "svn", "Slovenia", []string{"Republic of Slovenia"}},
+	{"sj", "sjm", "Svalbard and Jan Mayen", nil},
+	{"sk", "svk", "Slovakia", []string{"Slovak Republic"}},
+	{"sl", "sle", "Sierra Leone", []string{"Republic of Sierra Leone"}},
+	{"sm", "smr", "San Marino", []string{"Republic of San Marino"}},
+	{"sn", "sen", "Senegal", []string{"Republic of Senegal"}},
+	{"so", "som", "Somalia", []string{"Federal Republic of Somalia"}},
+	{"sr", "sur", "Suriname", []string{"Republic of Suriname"}},
+	{"ss", "ssd", "South Sudan", []string{"Republic of South Sudan"}},
+	{"st", "stp", "Sao Tome and Principe", []string{"Democratic Republic of Sao Tome and Principe"}},
+	{"sv", "slv", "El Salvador", []string{"Republic of El Salvador"}},
+	{"sx", "sxm", "Sint Maarten (Dutch part)", nil},
+	{"sy", "syr", "Syria", []string{"Syrian Arab Republic"}},
+	{"sz", "swz", "Eswatini", []string{"Kingdom of Eswatini"}},
+	{"tc", "tca", "Turks and Caicos Islands", nil},
+	{"td", "tcd", "Chad", []string{"Republic of Chad"}},
+	{"tf", "atf", "French Southern Territories", nil},
+	{"tg", "tgo", "Togo", []string{"Togolese Republic"}},
+	{"th", "tha", "Thailand", []string{"Kingdom of Thailand"}},
+	{"tj", "tjk", "Tajikistan", []string{"Republic of Tajikistan"}},
+	{"tk", "tkl", "Tokelau", nil},
+	{"tl", "tls", "Timor-Leste", []string{"Democratic Republic of Timor-Leste"}},
+	{"tm", "tkm", "Turkmenistan", nil},
+	{"tn", "tun", "Tunisia", []string{"Republic of Tunisia"}},
+	{"to", "ton", "Tonga", []string{"Kingdom of Tonga"}},
+	{"tr", "tur", "Türkiye", []string{"Republic of Türkiye"}},
+	{"tt", "tto", "Trinidad and Tobago", []string{"Republic of Trinidad and Tobago"}},
+	{"tv", "tuv", "Tuvalu", nil},
+	{"tw", "twn", "Taiwan", []string{"Taiwan, Province of China"}},
+	{"tz", "tza", "Tanzania", []string{"Tanzania, United Republic of", "United Republic of Tanzania"}},
+	{"ua", "ukr", "Ukraine", nil},
+	{"ug", "uga", "Uganda", []string{"Republic of Uganda"}},
+	{"um", "umi", "United States Minor Outlying Islands", nil},
+	{"us", "usa", "United States", []string{"United States of America"}},
+	{"uy", "ury", "Uruguay", []string{"Eastern Republic of Uruguay"}},
+	{"uz", "uzb", "Uzbekistan", []string{"Republic of Uzbekistan"}},
+	{"va", "vat", "Holy See (Vatican City State)", nil},
+	{"vc", "vct", "Saint Vincent and the Grenadines", nil},
+	{"ve", "ven", "Venezuela", []string{"Venezuela, Bolivarian Republic of", "Bolivarian Republic of Venezuela"}},
+	{"vg", "vgb", "Virgin Islands, British", []string{"British Virgin Islands"}},
+	{"vi", "vir", "Virgin Islands, U.S.", []string{"Virgin Islands of the United States"}},
+	{"vn", "vnm", "Vietnam", []string{"Viet Nam", "Socialist Republic of Viet Nam"}},
+	{"vu", "vut", "Vanuatu", []string{"Republic of Vanuatu"}},
+	{"wf", "wlf", "Wallis and Futuna", nil},
+	{"ws", "wsm", "Samoa", []string{"Independent State of Samoa"}},
+	{"ye", "yem", "Yemen", []string{"Republic of Yemen"}},
+	{"yt", "myt", "Mayotte", nil},
+	{"za", "zaf", "South Africa", []string{"Republic of South Africa"}},
+	{"zm", "zmb", "Zambia", []string{"Republic of Zambia"}},
+	{"zw", "zwe", "Zimbabwe", []string{"Republic of Zimbabwe"}},
+}
@@ -0,0 +1,221 @@
+package routedb
+
+import (
+	"bytes"
+	"container/heap"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// IsochroneOptions control how Isochrone models a journey.
+type IsochroneOptions struct {
+	// WalkMetersPerSecond is the walking speed, 1.3 by default.
+	WalkMetersPerSecond float64
+
+	// RideMetersPerSecond is the average speed of the vehicles on
+	// the routes, 5 (18 km/h) by default.
+	RideMetersPerSecond float64
+
+	// WaitSeconds is the time spent waiting each time a vehicle is
+	// boarded, 180 by default.
+	WaitSeconds float64
+
+	// TransferMeters is the furthest walk between alighting from one
+	// route and boarding another, 400 by default.
+	TransferMeters float64
+}
+
+// isochroneSides is the number of sides of the polygons approximating
+// the circles an isochrone is made of.
+const isochroneSides = 24
+
+// A circle is the area within r meters of c.
+type circle struct {
+	c Stop
+	r float64
+}
+
+// Isochrone returns the area reachable from the given point within
+// minutes, walking and riding the routes, as a Well-Known Text
+// MultiPolygon like RouteWKT's output. A nil opts gives the defaults.
+//
+// The journey is modelled approximately: walking goes in straight
+// lines, vehicles run along the routes in the direction of their
+// waypoints at a constant speed, and boarding costs a fixed wait. The
+// quickest time to reach each waypoint on foot is found by Dijkstra's
+// algorithm, and the area is the union of the circles around the
+// starting point and each waypoint that can be walked to in the time
+// left. Circles lying within larger ones are left out; the polygons
+// overlap, so draw them filled rather than outlined.
+func (db *Db) Isochrone(lat, lon float64, minutes float64, opts *IsochroneOptions) (string, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return "", err
+	}
+	if !(minutes >= 0) {
+		return "", errors.New("minutes must not be negative")
+	}
+	o := IsochroneOptions{1.3, 5, 180, 400}
+	if opts != nil {
+		if opts.WalkMetersPerSecond > 0 {
+			o.WalkMetersPerSecond = opts.WalkMetersPerSecond
+		}
+		if opts.RideMetersPerSecond > 0 {
+			o.RideMetersPerSecond = opts.RideMetersPerSecond
+		}
+		if opts.WaitSeconds > 0 {
+			o.WaitSeconds = opts.WaitSeconds
+		}
+		if opts.TransferMeters > 0 {
+			o.TransferMeters = opts.TransferMeters
+		}
+	}
+	return multiPolygonWKT(db.isochroneCircles(Stop{lat, lon}, minutes*60, o)), nil
+}
+
+// An isoState is being at a waypoint, either on foot or on board a
+// vehicle, in the search for an isochrone.
+type isoState struct {
+	ri, pi  int
+	onBoard bool
+}
+
+// An isoItem is a state reached at a time, in seconds.
+type isoItem struct {
+	s isoState
+	t float64
+}
+
+// isoQueue is a priority queue of isoItems, earliest first.
+type isoQueue []isoItem
+
+func (q isoQueue) Len() int            { return len(q) }
+func (q isoQueue) Less(i, j int) bool  { return q[i].t < q[j].t }
+func (q isoQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *isoQueue) Push(x interface{}) { *q = append(*q, x.(isoItem)) }
+func (q *isoQueue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// isochroneCircles returns the circles whose union is the area
+// reachable from p within budget seconds, as described for Isochrone.
+func (db *Db) isochroneCircles(p Stop, budget float64, o IsochroneOptions) []circle {
+	best := make(map[isoState]float64)
+	q := &isoQueue{}
+	push := func(s isoState, t float64) {
+		if t > budget {
+			return
+		}
+		if old, ok := best[s]; ok && old <= t {
+			return
+		}
+		best[s] = t
+		heap.Push(q, isoItem{s, t})
+	}
+	walk := func(from Stop, t, maxMeters float64) {
+		maxMeters = math.Min(maxMeters, (budget-t)*o.WalkMetersPerSecond)
+		for _, h := range db.withinAll(from, maxMeters) {
+			push(isoState{h.ri, h.pi, false}, t+h.d/o.WalkMetersPerSecond)
+		}
+	}
+
+	walk(p, 0, math.Inf(1))
+	for q.Len() > 0 {
+		it := heap.Pop(q).(isoItem)
+		s := it.s
+		if best[s] < it.t {
+			continue
+		}
+		pts := db.routes[s.ri].pts
+		if s.onBoard {
+			// Ride on, or get off.
+			if s.pi+1 < len(pts) {
+				ride := distance(pts[s.pi], pts[s.pi+1]) / o.RideMetersPerSecond
+				push(isoState{s.ri, s.pi + 1, true}, it.t+ride)
+			}
+			if s.pi > 0 {
+				// Having got off, walk to another route.
+				push(isoState{s.ri, s.pi, false}, it.t)
+				walk(pts[s.pi], it.t, o.TransferMeters)
+			}
+		} else {
+			push(isoState{s.ri, s.pi, true}, it.t+o.WaitSeconds)
+		}
+	}
+
+	circles := []circle{{p, budget * o.WalkMetersPerSecond}}
+	for s, t := range best {
+		if !s.onBoard {
+			circles = append(circles, circle{db.routes[s.ri].pts[s.pi], (budget - t) * o.WalkMetersPerSecond})
+		}
+	}
+	return outermost(circles)
+}
+
+// byRadius sorts circles largest first, and otherwise by position, so
+// that the order does not depend on that of a map.
+type byRadius []circle
+
+func (s byRadius) Len() int { return len(s) }
+func (s byRadius) Less(i, j int) bool {
+	if s[i].r != s[j].r {
+		return s[i].r > s[j].r
+	}
+	if s[i].c.Lat != s[j].c.Lat {
+		return s[i].c.Lat < s[j].c.Lat
+	}
+	return s[i].c.Lon < s[j].c.Lon
+}
+func (s byRadius) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// outermost returns the circles not lying within another, largest
+// first.
+func outermost(circles []circle) []circle {
+	sort.Sort(byRadius(circles))
+	var out []circle
+next:
+	for _, c := range circles {
+		for _, o := range out {
+			if distance(c.c, o.c)+c.r <= o.r {
+				continue next
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// multiPolygonWKT returns the circles as a Well-Known Text
+// MultiPolygon of regular polygons, each with its vertices on the
+// circle, counterclockwise.
+func multiPolygonWKT(circles []circle) string {
+	if len(circles) == 0 {
+		return "MULTIPOLYGON EMPTY"
+	}
+	var buf bytes.Buffer
+	buf.WriteString("MULTIPOLYGON (")
+	for k, c := range circles {
+		if k > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString("((")
+		dlat := c.r / metersPerDegree
+		dlon := dlat / math.Max(math.Cos(c.c.Lat*math.Pi/180), 1e-6)
+		for j := 0; j <= isochroneSides; j++ {
+			if j > 0 {
+				buf.WriteString(", ")
+			}
+			a := 2 * math.Pi * float64(j%isochroneSides) / isochroneSides
+			buf.WriteString(strconv.FormatFloat(c.c.Lon+dlon*math.Cos(a), 'f', 6, 64))
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatFloat(c.c.Lat+dlat*math.Sin(a), 'f', 6, 64))
+		}
+		buf.WriteString("))")
+	}
+	buf.WriteByte(')')
+	return buf.String()
+}
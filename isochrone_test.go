@@ -0,0 +1,72 @@
+package routedb
+
+import (
+	"strings"
+	"testing"
+)
+
+// covered reports whether p is within one of circles.
+func covered(circles []circle, p Stop) bool {
+	for _, c := range circles {
+		if distance(c.c, p) <= c.r {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIsochrone(t *testing.T) {
+	db := makeDb(t,
+		// East from the start, 4.2 km in 6 segments.
+		testRoute{"kg-osh-east", []float64{40.5, 72.80, 40.5, 72.81, 40.5, 72.82, 40.5, 72.83, 40.5, 72.84, 40.5, 72.85}},
+		// North from near the end of kg-osh-east.
+		testRoute{"kg-osh-north", []float64{40.501, 72.85, 40.51, 72.85, 40.52, 72.85}},
+	)
+	o := IsochroneOptions{WalkMetersPerSecond: 1, RideMetersPerSecond: 10, WaitSeconds: 60, TransferMeters: 200}
+	start := Stop{40.5, 72.80}
+
+	// Ten minutes: a 600 m walk, or a wait and 540 s ride, which
+	// gets to the end of kg-osh-east but not to transfer.
+	circles := db.isochroneCircles(start, 600, o)
+	for _, tc := range []struct {
+		p    Stop
+		want bool
+	}{
+		{Stop{40.5, 72.80}, true},
+		{Stop{40.505, 72.80}, true},
+		{Stop{40.5, 72.849}, true},
+		{Stop{40.51, 72.80}, false},
+		{Stop{40.51, 72.85}, false},
+	} {
+		if got := covered(circles, tc.p); got != tc.want {
+			t.Errorf("10 minutes: %v covered %v, expected %v", tc.p, got, tc.want)
+		}
+	}
+
+	// Fifteen minutes is time to transfer north.
+	circles = db.isochroneCircles(start, 900, o)
+	if !covered(circles, Stop{40.51, 72.85}) {
+		t.Error("15 minutes: transfer north not covered")
+	}
+	for k, c := range circles {
+		for _, o := range circles[:k] {
+			if distance(c.c, o.c)+c.r <= o.r {
+				t.Errorf("circle %v lies within %v", c, o)
+			}
+		}
+	}
+
+	wkt, err := db.Isochrone(40.5, 72.80, 15, &o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(wkt, "MULTIPOLYGON (((") || strings.Count(wkt, "((") != len(circles) {
+		t.Errorf("expected %v polygons in %.60v...", len(circles), wkt)
+	}
+	if _, err := db.Isochrone(40.5, 72.80, -1, nil); err == nil {
+		t.Error("expected error for negative time")
+	}
+	if _, err := db.Isochrone(40.5, 182, 10, nil); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
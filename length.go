@@ -0,0 +1,90 @@
+package routedb
+
+import "math"
+
+// RouteLength returns the length in meters of route i, following its
+// path from waypoint to waypoint. Lengths are computed when the route
+// is loaded, and kept up to date by the methods changing routes, so
+// this is cheap to call repeatedly.
+func (db *Db) RouteLength(i int) (float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, err
+	}
+	return t.length, nil
+}
+
+// cumulative returns the distance in meters along the path pts of each
+// of its points.
+func cumulative(pts []Stop) []float64 {
+	cum := make([]float64, len(pts))
+	for j := 1; j < len(pts); j++ {
+		cum[j] = cum[j-1] + distance(pts[j-1], pts[j])
+	}
+	return cum
+}
+
+// alongPath returns how far in meters along the path pts, which must
+// not be empty, the point on it nearest to p is.
+func alongPath(pts []Stop, p Stop) float64 {
+	_, seg, f, _ := snap(p, pts)
+	meters := pathLength(pts[:seg+1])
+	if seg+1 < len(pts) {
+		meters += f * distance(pts[seg], pts[seg+1])
+	}
+	return meters
+}
+
+// RouteProgress projects the given point onto the path of route i,
+// returning how far along the route the projection is, in meters from
+// the start and as a fraction of the route's length from 0 to 1, for
+// showing progress along the route. A route of no length gives 0.
+func (db *Db) RouteProgress(i int, lat, lon float64) (meters, fraction float64, err error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := checkLatLon(lat, lon); err != nil {
+		return 0, 0, err
+	}
+	if len(t.pts) == 0 {
+		return 0, 0, errEmptyRoute
+	}
+	meters = alongPath(t.pts, Stop{lat, lon})
+	if t.length > 0 {
+		fraction = meters / t.length
+	}
+	return meters, fraction, nil
+}
+
+// PointAtDistance returns the point meters along the path of route i
+// from its start, and the bearing in degrees of the path there, for
+// moving a vehicle icon smoothly along the route. Distances beyond
+// either end of the route give the end. The bearing is that of the
+// segment the point is on; a route with a single waypoint has a
+// bearing of 0.
+func (db *Db) PointAtDistance(i int, meters float64) (stop *Stop, bearingDegrees float64, err error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(t.pts) == 0 {
+		return nil, 0, errEmptyRoute
+	}
+	p, b := t.pts[0], 0.0
+	for j := 1; j < len(t.pts); j++ {
+		a, c := t.pts[j-1], t.pts[j]
+		l := distance(a, c)
+		if l == 0 {
+			continue
+		}
+		b = bearing(a, c)
+		if meters <= l {
+			p = interpolate(a, c, math.Max(0, meters)/l)
+			return &Stop{Lat: p.Lat, Lon: p.Lon}, b, nil
+		}
+		meters -= l
+		p = c
+	}
+	return &Stop{Lat: p.Lat, Lon: p.Lon}, b, nil
+}
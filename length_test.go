@@ -0,0 +1,155 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRouteLength(t *testing.T) {
+	sdb := makeDb(t, testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.51, 72.81}})
+
+	l, err := sdb.RouteLength(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := distance(Stop{40.5, 72.80}, Stop{40.5, 72.81}) + distance(Stop{40.5, 72.81}, Stop{40.51, 72.81})
+	if math.Abs(l-exp) > 1e-6 {
+		t.Errorf("length is %v, expected %v", l, exp)
+	}
+
+	// The cached length of the fixture route matches a fresh
+	// computation.
+	l, err = db.RouteLength(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l != pathLength(db.routes[0].pts) {
+		t.Errorf("cached length %v differs from %v", l, pathLength(db.routes[0].pts))
+	}
+
+	if _, err := db.RouteLength(1); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+// TestRouteLengthKeptCurrent checks that the cached lengths follow the
+// changes made to routes.
+func TestRouteLengthKeptCurrent(t *testing.T) {
+	check := func(what string, db *Db) {
+		for i, r := range db.routes {
+			if l, _ := db.RouteLength(i); l != pathLength(r.pts) {
+				t.Errorf("%v: route %v length %v, expected %v", what, i, l, pathLength(r.pts))
+			}
+		}
+	}
+	adb := loadTestdata(t, "testdata/antimeridian.zip")
+	check("loaded", adb)
+	adb.FixAntimeridian()
+	check("fixed antimeridian", adb)
+
+	sdb := loadTestdata(t, "testdata/routedb.zip")
+	sdb.RemoveColinear(10)
+	check("removed colinear", sdb)
+
+	b := NewBuilder()
+	b.AddRoute("kg", "osh", "1", []float64{40.5, 40.51}, []float64{72.8, 72.81})
+	bdb, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("built", bdb)
+}
+
+func BenchmarkRouteLength(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		db.RouteLength(0)
+	}
+}
+
+func BenchmarkPathLength(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		pathLength(db.routes[0].pts)
+	}
+}
+
+func TestRouteProgress(t *testing.T) {
+	sdb := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.51, 72.81}},
+		testRoute{"kg-osh-2", []float64{40.5, 72.80}},
+	)
+	leg := distance(Stop{40.5, 72.80}, Stop{40.5, 72.81})
+	length, _ := sdb.RouteLength(0)
+
+	for _, tc := range []struct {
+		lat, lon float64
+		want     float64
+	}{
+		{40.5, 72.80, 0},
+		{40.4999, 72.805, leg / 2},
+		{40.505, 72.8101, leg + distance(Stop{40.5, 72.81}, Stop{40.505, 72.81})},
+		{40.52, 72.81, length},
+		{40.49, 72.79, 0},
+	} {
+		m, f, err := sdb.RouteProgress(0, tc.lat, tc.lon)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(m-tc.want) > 1 {
+			t.Errorf("progress at %v, %v is %v meters, expected %v", tc.lat, tc.lon, m, tc.want)
+		}
+		if math.Abs(f-m/length) > 1e-9 {
+			t.Errorf("fraction at %v, %v is %v, expected %v", tc.lat, tc.lon, f, m/length)
+		}
+	}
+
+	if m, f, err := sdb.RouteProgress(1, 40.6, 72.9); m != 0 || f != 0 || err != nil {
+		t.Errorf("progress on single stop route is %v, %v, %v", m, f, err)
+	}
+	if _, _, err := sdb.RouteProgress(2, 40.5, 72.8); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if _, _, err := sdb.RouteProgress(0, 40.5, 190); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
+
+func TestPointAtDistance(t *testing.T) {
+	sdb := makeDb(t,
+		// East, a repeated waypoint, then north.
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.5, 72.81, 40.51, 72.81}},
+		testRoute{"kg-osh-2", []float64{40.5, 72.80}},
+	)
+	leg := distance(Stop{40.5, 72.80}, Stop{40.5, 72.81})
+	length, _ := sdb.RouteLength(0)
+
+	for _, tc := range []struct {
+		meters  float64
+		want    Stop
+		bearing float64
+	}{
+		{-10, Stop{40.5, 72.80}, 90},
+		{0, Stop{40.5, 72.80}, 90},
+		{leg / 2, Stop{40.5, 72.805}, 90},
+		{leg, Stop{40.5, 72.81}, 90},
+		{leg + (length-leg)/2, Stop{40.505, 72.81}, 0},
+		{length + 10, Stop{40.51, 72.81}, 0},
+	} {
+		s, b, err := sdb.PointAtDistance(0, tc.meters)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := distance(*s, tc.want); d > 0.5 {
+			t.Errorf("point at %v is %v, %v meters from %v", tc.meters, *s, d, tc.want)
+		}
+		if math.Abs(b-tc.bearing) > 0.1 && math.Abs(b-tc.bearing-360) > 0.1 {
+			t.Errorf("bearing at %v is %v, expected %v", tc.meters, b, tc.bearing)
+		}
+	}
+
+	if s, b, err := sdb.PointAtDistance(1, 100); err != nil || *s != (Stop{40.5, 72.80}) || b != 0 {
+		t.Errorf("point on single stop route is %v, %v, %v", s, b, err)
+	}
+	if _, _, err := sdb.PointAtDistance(-1, 0); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
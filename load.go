@@ -0,0 +1,97 @@
+package routedb
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// LoadAuto loads a routedb from in, which may be a zip of GPX files
+// (as taken by Load), a single GPX file, or a gzipped GPX file. The
+// format is detected from the leading bytes of in.
+func LoadAuto(in []byte) (*Db, error) {
+	switch {
+	case bytes.HasPrefix(in, []byte("PK")):
+		return Load(in)
+	case bytes.HasPrefix(in, []byte{0x1f, 0x8b}):
+		return loadGzip(in)
+	case bytes.HasPrefix(bytes.TrimLeft(bytes.TrimPrefix(in, []byte("\xef\xbb\xbf")), " \t\r\n"), []byte("<")):
+		return loadGPX(in)
+	}
+	return nil, errors.New("Unknown format: expected zip, gzip or GPX")
+}
+
+// LoadContext is like Load, but gives up and returns ctx.Err() if ctx
+// is done before loading finishes. It is not available through
+// gobind, which cannot bind a context.
+func LoadContext(ctx context.Context, in []byte) (*Db, error) {
+	return loadZip(ctx, in, nil)
+}
+
+// LoadAll loads several routedbs, as taken by Load, into one Db. The
+// routes keep the order in which they appear in ins. Routes appearing
+// in more than one input are all kept, each with its own RouteID. The
+// IDs given in the metadata of routes are kept, and it is an error for
+// two inputs to give the same one.
+func LoadAll(ins ...[]byte) (*Db, error) {
+	all := &Db{}
+	given := make(map[string]bool)
+	for k, in := range ins {
+		db, err := Load(in)
+		if err != nil {
+			return nil, fmt.Errorf("In input %v: %v", k, err)
+		}
+		for _, t := range db.routes {
+			if !t.idGiven {
+				// The derived IDs must be unique across all
+				// the inputs, so derive them again.
+				t.id = ""
+				continue
+			}
+			if given[t.id] {
+				return nil, fmt.Errorf("In input %v: route id %v is repeated", k, t.id)
+			}
+			given[t.id] = true
+		}
+		all.routes = append(all.routes, db.routes...)
+		all.warnings = append(all.warnings, db.warnings...)
+	}
+	all.assignIDs()
+	all.RecomputeBounds()
+	return all, nil
+}
+
+// loadGPX loads a routedb made of the single GPX file in.
+func loadGPX(in []byte) (*Db, error) {
+	t, err := parseTrack("gpx", bytes.NewReader(in))
+	if err != nil {
+		return nil, err
+	}
+	db := &Db{}
+	db.addTrack("gpx", t, nil)
+	if err := db.finishLoad(nil, nil, nil, nil); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// loadGzip loads a routedb made of the single gzipped GPX file in.
+func loadGzip(in []byte) (*Db, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(in))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	t, err := parseTrack("gzip", zr)
+	if err != nil {
+		return nil, err
+	}
+	db := &Db{}
+	db.addTrack("gzip", t, nil)
+	if err := db.finishLoad(nil, nil, nil, nil); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
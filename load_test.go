@@ -0,0 +1,187 @@
+package routedb
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// fixtureGPX returns the GPX file inside testdata/routedb.zip.
+func fixtureGPX(t *testing.T) []byte {
+	in, err := ioutil.ReadFile("testdata/routedb.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(in), int64(len(in)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	buf, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestLoadAuto(t *testing.T) {
+	zipped, err := ioutil.ReadFile("testdata/routedb.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bare := fixtureGPX(t)
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write(bare)
+	w.Close()
+
+	for _, tc := range []struct {
+		name string
+		in   []byte
+	}{
+		{"zip", zipped},
+		{"gpx", bare},
+		{"gzip", gz.Bytes()},
+	} {
+		db2, err := LoadAuto(tc.in)
+		if err != nil {
+			t.Errorf("%v: %v", tc.name, err)
+			continue
+		}
+		if db2.Routes() != 1 {
+			t.Errorf("%v: got %v routes", tc.name, db2.Routes())
+		}
+		if *db2.Bounds() != *db.Bounds() {
+			t.Errorf("%v: bounds %v, expected %v", tc.name, *db2.Bounds(), *db.Bounds())
+		}
+	}
+
+	if _, err := LoadAuto([]byte("garbage")); err == nil {
+		t.Error("expected error for unknown format")
+	}
+
+	// A bare or gzipped GPX file is processed as it would be in a
+	// zip, with its country normalized and its agency looked up.
+	src := []byte(strings.Replace(agencyGPX("oshbus"), "kg-osh-1", "KGZ-osh-1", 1))
+	gz.Reset()
+	w = gzip.NewWriter(&gz)
+	w.Write(src)
+	w.Close()
+	for _, in := range [][]byte{src, gz.Bytes()} {
+		db2, err := LoadAuto(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c, _ := db2.RouteCountry(0); c != "kg" || db2.Countries() != "kg" {
+			t.Errorf("country is %v", c)
+		}
+		if !strings.Contains(db2.Warnings(), "unknown agency oshbus") {
+			t.Errorf("warnings are %q", db2.Warnings())
+		}
+	}
+}
+
+// A countdownCtx is a context that is cancelled after its Err method
+// has been called n times.
+type countdownCtx struct {
+	context.Context
+	n int
+}
+
+func (c *countdownCtx) Err() error {
+	if c.n <= 0 {
+		return context.Canceled
+	}
+	c.n--
+	return nil
+}
+
+func TestLoadContext(t *testing.T) {
+	in, err := ioutil.ReadFile("testdata/cities.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := LoadContext(context.Background(), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Routes() != 4 {
+		t.Errorf("got %v routes, expected 4", db.Routes())
+	}
+
+	// Cancel after two of the four files.
+	ctx := &countdownCtx{Context: context.Background(), n: 2}
+	if _, err := LoadContext(ctx, in); err != context.Canceled {
+		t.Errorf("error is %v, expected %v", err, context.Canceled)
+	}
+
+	cctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := LoadContext(cctx, in); err != context.Canceled {
+		t.Errorf("error is %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestLoadAll(t *testing.T) {
+	in1, err := ioutil.ReadFile("testdata/routedb.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	in2, err := ioutil.ReadFile("testdata/cities.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db2, err := LoadAll(in1, in1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db2.Routes() != 2*db.Routes() {
+		t.Errorf("got %v routes, expected %v", db2.Routes(), 2*db.Routes())
+	}
+	if *db2.Bounds() != *db.Bounds() {
+		t.Errorf("bounds are %v, expected %v", *db2.Bounds(), *db.Bounds())
+	}
+	id0, _ := db2.RouteID(0)
+	id1, _ := db2.RouteID(1)
+	if id0 == id1 {
+		t.Errorf("both copies have id %v", id0)
+	}
+
+	db2, err = LoadAll(in2, in1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db2.Routes() != 5 || db2.routes[4].md != "kg-osh-149" || db2.routes[2].md != "kg-bishkek-5" {
+		t.Errorf("routes are out of order")
+	}
+
+	if _, err := LoadAll(in1, []byte("garbage")); err == nil {
+		t.Error("expected error for bad input")
+	}
+
+	// The IDs given in metadata are kept, and must not repeat.
+	given := zipFiles(t, "7.gpx", plainGPX("kg-osh-7"), "7.json", `{"id": "osh-7"}`)
+	plain := zipFiles(t, "7.gpx", plainGPX("kg-osh-7"))
+	db2, err = LoadAll(plain, given, plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"kg-osh-7", "osh-7", "kg-osh-7#2"} {
+		if id, _ := db2.RouteID(i); id != want {
+			t.Errorf("route %v id is %v, expected %v", i, id, want)
+		}
+	}
+	if _, err := LoadAll(given, given); err == nil {
+		t.Error("expected error for repeated id")
+	}
+}
@@ -0,0 +1,76 @@
+package routedb
+
+import "strings"
+
+// normalizeLocale returns locale, such as "ru_KG", in the form used to
+// look up localized names, such as "ru-kg".
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(locale), "_", "-", -1))
+}
+
+// localizedNames returns the names in ns by locale, or nil if there
+// are none. Names without a locale are ignored.
+func localizedNames(ns []gpxName) map[string]string {
+	var names map[string]string
+	for _, n := range ns {
+		lang, name := normalizeLocale(n.Lang), strings.TrimSpace(n.Name)
+		if lang == "" || name == "" {
+			continue
+		}
+		if names == nil {
+			names = make(map[string]string)
+		}
+		names[lang] = name
+	}
+	return names
+}
+
+// localized returns the name for locale in names. A regional locale
+// such as "ru-KG" falls back to its language, "ru", and a locale with
+// no name falls back to def.
+func localized(names map[string]string, locale, def string) string {
+	locale = normalizeLocale(locale)
+	if locale == "" {
+		return def
+	}
+	if name, ok := names[locale]; ok {
+		return name
+	}
+	if k := strings.Index(locale, "-"); k > 0 {
+		if name, ok := names[locale[:k]]; ok {
+			return name
+		}
+	}
+	return def
+}
+
+// name returns the name of t in locale, as for RouteName.
+func (t *track) name(locale string) string {
+	_, _, name := t.split()
+	return localized(t.names, locale, name)
+}
+
+// SetLocale sets the locale, such as "ky", "ru" or "en-GB", of the
+// names of routes and stops returned from then on by Route and the
+// other methods returning a Route FlatBuffer, by RouteHighPrecision,
+// SummaryJSON and VectorTile, by RouteNamedStops and by StopByID. Names not given for the locale are returned as in the
+// metadata; the empty locale, the default, always returns them so.
+//
+// Like all the methods changing the database, it must not be called
+// concurrently with queries.
+func (db *Db) SetLocale(locale string) {
+	db.locale = locale
+}
+
+// RouteName returns the name of route i in the given locale, whatever
+// the locale set by SetLocale. Localized names are given in the
+// extensions of the track in the route's GPX file, as described for
+// trackExtensions. A name not given for the locale, or the language
+// of a regional locale, is returned as in the metadata name.
+func (db *Db) RouteName(i int, locale string) (string, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return "", err
+	}
+	return t.name(locale), nil
+}
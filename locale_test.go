@@ -0,0 +1,81 @@
+package routedb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+var localeGPX = `<gpx><metadata><name>kg-osh-7</name></metadata>
+<wpt lat="40.5" lon="72.8"><name>Bazaar</name><type>stop</type>
+<extensions><name lang="ru">Базар</name><name xml:lang="ky">Базар</name><name lang="en-GB">Market</name></extensions></wpt>
+<trk><extensions><name lang="ru">Маршрут 7</name><name lang="ky">7-маршрут</name></extensions>
+<trkseg><trkpt lat="40.5" lon="72.8"/><trkpt lat="40.5" lon="72.801"/></trkseg></trk></gpx>`
+
+func TestRouteName(t *testing.T) {
+	sdb, err := Load(zipGPX(t, localeGPX))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		locale, want string
+	}{
+		{"", "7"},
+		{"ru", "Маршрут 7"},
+		{"RU_kg", "Маршрут 7"},
+		{"ky", "7-маршрут"},
+		{"en", "7"},
+	} {
+		name, err := sdb.RouteName(0, tc.locale)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != tc.want {
+			t.Errorf("name in %q is %q, expected %q", tc.locale, name, tc.want)
+		}
+	}
+	if _, err := sdb.RouteName(1, "ru"); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
+
+func TestSetLocale(t *testing.T) {
+	sdb, err := Load(zipGPX(t, localeGPX))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := stopID(Stop{40.5, 72.8}, "Bazaar")
+	for _, tc := range []struct {
+		locale, route, stop string
+	}{
+		{"ru", "Маршрут 7", "Базар"},
+		{"en-GB", "7", "Market"},
+		{"en-US", "7", "Bazaar"},
+		{"", "7", "Bazaar"},
+	} {
+		sdb.SetLocale(tc.locale)
+		buf, _ := sdb.Route(0)
+		if name := string(route.GetRootAsRoute(buf, 0).Name()); name != tc.route {
+			t.Errorf("route name in %q is %q, expected %q", tc.locale, name, tc.route)
+		}
+		buf, _ = sdb.RouteHighPrecision(0)
+		if name := string(route.GetRootAsRouteHP(buf, 0).Name()); name != tc.route {
+			t.Errorf("high precision route name in %q is %q, expected %q", tc.locale, name, tc.route)
+		}
+		summary, _ := sdb.SummaryJSON()
+		if !bytes.Contains(summary, []byte(`"name":"`+tc.route+`"`)) {
+			t.Errorf("summary in %q is %s, expected name %q", tc.locale, summary, tc.route)
+		}
+		x, y := tileFor(14, 40.5, 72.8005)
+		tile, _ := sdb.VectorTile(14, x, y)
+		if !bytes.Contains(tile, []byte(tc.route)) {
+			t.Errorf("vector tile in %q does not name the route %q", tc.locale, tc.route)
+		}
+		stops, _ := sdb.RouteNamedStops(0)
+		s, _ := sdb.StopByID(id)
+		if stops[0].Name != tc.stop || s.Name != tc.stop || s.ID != id {
+			t.Errorf("stop name in %q is %q, %q, expected %q", tc.locale, stops[0].Name, s.Name, tc.stop)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package routedb
+
+import (
+	"errors"
+	"math"
+)
+
+// matchMeters is the average distance between a trace and a route at
+// which a match is considered to have no confidence at all.
+const matchMeters = 50
+
+// traceStops returns the points of the trace given by the parallel
+// slices lats and lons, checking that there is at least one and that
+// they are all valid.
+func traceStops(lats, lons []float64) ([]Stop, error) {
+	if len(lats) != len(lons) {
+		return nil, errors.New("lats and lons differ in length")
+	}
+	if len(lats) == 0 {
+		return nil, errors.New("empty trace")
+	}
+	trace := make([]Stop, len(lats))
+	for k := range lats {
+		if err := checkLatLon(lats[k], lons[k]); err != nil {
+			return nil, err
+		}
+		trace[k] = Stop{lats[k], lons[k]}
+	}
+	return trace, nil
+}
+
+// MatchTraceScored finds the route best matching the trace given by
+// the parallel slices lats and lons: the one whose path the trace
+// points lie closest to on average. It returns the index of that
+// route, a confidence score, and each trace point snapped onto the
+// route.
+//
+// The score runs from 1, when the trace lies exactly on the route,
+// down to 0 when the average distance between them is matchMeters or
+// more. A low score means the trace was probably not made on any of
+// the routes in the database.
+func (db *Db) MatchTraceScored(lats, lons []float64) (routeIndex int, score float64, snapped []*Stop, err error) {
+	trace, err := traceStops(lats, lons)
+	if err != nil {
+		return -1, 0, nil, err
+	}
+
+	routeIndex = -1
+	best := math.Inf(1)
+	for i, t := range db.routes {
+		if len(t.pts) == 0 {
+			continue
+		}
+		sum := 0.0
+		for _, p := range trace {
+			_, _, _, d := snap(p, t.pts)
+			sum += d
+		}
+		if avg := sum / float64(len(trace)); avg < best {
+			routeIndex, best = i, avg
+		}
+	}
+	if routeIndex < 0 {
+		return -1, 0, nil, errNoStop
+	}
+
+	pts := db.routes[routeIndex].pts
+	snapped = make([]*Stop, len(trace))
+	for k, p := range trace {
+		q, _, _, _ := snap(p, pts)
+		snapped[k] = &Stop{Lat: q.Lat, Lon: q.Lon}
+	}
+	score = math.Max(0, 1-best/matchMeters)
+	return routeIndex, score, snapped, nil
+}
+
+// OffRoute reports whether the given position, such as that of a bus
+// meant to be on route i, is further than thresholdMeters from the
+// route's path, along with its distance from the path in meters.
+func (db *Db) OffRoute(i int, lat, lon float64, thresholdMeters float64) (off bool, meters float64, err error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return false, 0, err
+	}
+	if err := checkLatLon(lat, lon); err != nil {
+		return false, 0, err
+	}
+	if len(t.pts) == 0 {
+		return false, 0, errEmptyRoute
+	}
+	_, _, _, d := snap(Stop{lat, lon}, t.pts)
+	return d > thresholdMeters, d, nil
+}
@@ -0,0 +1,77 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatchTraceScored(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.50, 72.82, 40.52, 72.82}},
+		testRoute{"kg-osh-2", []float64{40.55, 72.80, 40.55, 72.85}},
+	)
+
+	// A trace a few meters off route 0.
+	ri, score, snapped, err := db.MatchTraceScored(
+		[]float64{40.50002, 40.50003, 40.505, 40.515},
+		[]float64{72.805, 72.815, 72.82003, 72.81997})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ri != 0 {
+		t.Errorf("matched route %v, expected 0", ri)
+	}
+	if score < 0.9 {
+		t.Errorf("on-route trace scored %v", score)
+	}
+	if len(snapped) != 4 {
+		t.Fatalf("got %v snapped points", len(snapped))
+	}
+	if d := distance(*snapped[0], Stop{40.5, 72.805}); d > 0.5 {
+		t.Errorf("snapped point %v is %v m off", *snapped[0], d)
+	}
+
+	// A trace a kilometer away from everything.
+	_, score, _, err = db.MatchTraceScored([]float64{40.53, 40.53}, []float64{72.79, 72.78})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score > 0.1 {
+		t.Errorf("off-network trace scored %v", score)
+	}
+
+	if _, _, _, err := db.MatchTraceScored([]float64{40.5}, nil); err == nil {
+		t.Error("expected error for mismatched lengths")
+	}
+	if _, _, _, err := (&Db{}).MatchTraceScored([]float64{40.5}, []float64{72.8}); err == nil {
+		t.Error("expected no stop error on empty db")
+	}
+}
+
+func TestOffRoute(t *testing.T) {
+	db := makeDb(t, testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.82}})
+	for _, tc := range []struct {
+		lat, lon float64
+		off      bool
+		meters   float64
+	}{
+		{40.5, 72.81, false, 0},
+		{40.5003, 72.81, false, 33},
+		{40.5006, 72.81, true, 67},
+		{40.5, 72.8206, true, 51},
+	} {
+		off, m, err := db.OffRoute(0, tc.lat, tc.lon, 50)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if off != tc.off || math.Abs(m-tc.meters) > 1 {
+			t.Errorf("%v, %v: off %v by %v, expected %v by %v", tc.lat, tc.lon, off, m, tc.off, tc.meters)
+		}
+	}
+	if _, _, err := db.OffRoute(1, 40.5, 72.8, 50); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if _, _, err := db.OffRoute(0, 40.5, math.Inf(1), 50); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
@@ -0,0 +1,105 @@
+package routedb
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/flatbuffers/go"
+	"github.com/jeffallen/routedb/route"
+)
+
+// StopDistanceMatrix returns the distances in meters along the routes
+// between stops, for journey planning and fare tables, as a FlatBuffer
+// holding a DistanceMatrix. The stops are those packed into latlons as
+// for BatchNearest or, if there are none, all the stops returned by
+// UniqueStops. A route serves a stop if it has a waypoint within
+// toleranceMeters of it.
+//
+// The matrix has a row and a column for each stop, in the order of
+// the DistanceMatrix's stops, and its meters are stored row by row:
+// the distance from stop a to stop b is meters[a*n+b] for n stops. It
+// is the shortest ride from a to b on a single route, in the direction
+// of its waypoints, and +Inf if no route goes from one to the other.
+// The distance from a stop to itself is 0.
+func (db *Db) StopDistanceMatrix(latlons []byte, toleranceMeters float64) ([]byte, error) {
+	stops, err := unpackLatLons(latlons)
+	if err != nil {
+		return nil, err
+	}
+	if len(stops) == 0 {
+		stops = db.clusterStops(toleranceMeters, nil)
+	}
+	return finishDistanceMatrix(stops, db.stopDistances(stops, toleranceMeters)), nil
+}
+
+// A stopVisit is a route passing a stop, at a distance in meters along
+// the route.
+type stopVisit struct {
+	stop  int
+	along float64
+}
+
+// byAlong sorts stopVisits by distance along the route.
+type byAlong []stopVisit
+
+func (s byAlong) Len() int           { return len(s) }
+func (s byAlong) Less(i, j int) bool { return s[i].along < s[j].along }
+func (s byAlong) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// stopDistances returns the matrix of distances between stops
+// described for StopDistanceMatrix, row by row.
+func (db *Db) stopDistances(stops []Stop, tol float64) []float64 {
+	n := len(stops)
+	m := make([]float64, n*n)
+	for k := range m {
+		m[k] = math.Inf(1)
+	}
+	for a := 0; a < n; a++ {
+		m[a*n+a] = 0
+	}
+
+	// The visits of each route to the stops.
+	cums := make([][]float64, len(db.routes))
+	visits := make([][]stopVisit, len(db.routes))
+	for s, stop := range stops {
+		for _, h := range db.withinAll(stop, tol) {
+			if cums[h.ri] == nil {
+				cums[h.ri] = cumulative(db.routes[h.ri].pts)
+			}
+			visits[h.ri] = append(visits[h.ri], stopVisit{s, cums[h.ri][h.pi]})
+		}
+	}
+	for _, vs := range visits {
+		sort.Stable(byAlong(vs))
+		for j, from := range vs {
+			for _, to := range vs[j+1:] {
+				if d := to.along - from.along; d < m[from.stop*n+to.stop] {
+					m[from.stop*n+to.stop] = d
+				}
+			}
+		}
+	}
+	return m
+}
+
+// finishDistanceMatrix returns a finished FlatBuffer holding a
+// DistanceMatrix of stops and meters.
+func finishDistanceMatrix(stops []Stop, meters []float64) []byte {
+	b := flatbuffers.NewBuilder(0)
+	route.DistanceMatrixStartMetersVector(b, len(meters))
+	for k := len(meters) - 1; k >= 0; k-- {
+		b.PrependFloat32(float32(meters[k]))
+	}
+	m := b.EndVector(len(meters))
+	route.DistanceMatrixStartStopsVector(b, len(stops))
+	for k := len(stops) - 1; k >= 0; k-- {
+		route.CreateGeoPoint(b, micro(stops[k].Lat), micro(stops[k].Lon))
+	}
+	s := b.EndVector(len(stops))
+
+	route.DistanceMatrixStart(b)
+	route.DistanceMatrixAddStops(b, s)
+	route.DistanceMatrixAddMeters(b, m)
+	b.Finish(route.DistanceMatrixEnd(b))
+	return b.Bytes[b.Head():]
+}
@@ -0,0 +1,59 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestStopDistanceMatrix(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.51, 72.80, 40.51, 72.81}},
+		// Back from the last stop of kg-osh-1 to its first, the
+		// long way round.
+		testRoute{"kg-osh-2", []float64{40.51, 72.81, 40.50, 72.81, 40.50, 72.80}},
+	)
+	up := distance(Stop{40.50, 72.80}, Stop{40.51, 72.80})
+	across := distance(Stop{40.51, 72.80}, Stop{40.51, 72.81})
+	down := distance(Stop{40.51, 72.81}, Stop{40.50, 72.81})
+	back := distance(Stop{40.50, 72.81}, Stop{40.50, 72.80})
+
+	buf, err := db.StopDistanceMatrix(pack(40.50, 72.80, 40.51, 72.80, 40.51, 72.81, 40.6, 72.9), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm := route.GetRootAsDistanceMatrix(buf, 0)
+	if dm.StopsLength() != 4 || dm.MetersLength() != 16 {
+		t.Fatalf("got %v stops and %v distances", dm.StopsLength(), dm.MetersLength())
+	}
+	inf := math.Inf(1)
+	want := [][]float64{
+		{0, up, up + across, inf},
+		{inf, 0, across, inf},
+		{down + back, inf, 0, inf},
+		{inf, inf, inf, 0},
+	}
+	for a := range want {
+		for b, exp := range want[a] {
+			got := float64(dm.Meters(a*4 + b))
+			if math.IsInf(exp, 1) && !math.IsInf(got, 1) || math.Abs(got-exp) > 0.01 {
+				t.Errorf("distance from %v to %v is %v, expected %v", a, b, got, exp)
+			}
+		}
+	}
+
+	// All the unique stops.
+	buf, err = db.StopDistanceMatrix(nil, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dm = route.GetRootAsDistanceMatrix(buf, 0)
+	if n := len(db.UniqueStops(5)); dm.StopsLength() != n || dm.MetersLength() != n*n {
+		t.Errorf("got %v stops and %v distances, expected %v stops", dm.StopsLength(), dm.MetersLength(), n)
+	}
+
+	if _, err := db.StopDistanceMatrix(pack(40.5), 5); err == nil {
+		t.Error("expected error for odd number of coordinates")
+	}
+}
@@ -0,0 +1,56 @@
+package routedb
+
+import "math"
+
+// MedianStop returns the geometric median of all the waypoints in the
+// database: the point minimizing the sum of the distances to them.
+// Unlike the centroid, it is not dragged away by a few outliers, which
+// makes it a good place for a single representative pin.
+//
+// It is found with Weiszfeld's iteration, starting from the centroid,
+// with distances measured on a plane tangent at the centroid's
+// latitude. That is accurate enough at the scale of a city.
+func (db *Db) MedianStop() (*Stop, error) {
+	var m Stop
+	n := 0
+	for _, t := range db.routes {
+		for _, pt := range t.pts {
+			m.Lat += pt.Lat
+			m.Lon += pt.Lon
+			n++
+		}
+	}
+	if n == 0 {
+		return nil, errNoStop
+	}
+	m.Lat /= float64(n)
+	m.Lon /= float64(n)
+	k := math.Cos(m.Lat * math.Pi / 180)
+
+	for iter := 0; iter < 1000; iter++ {
+		var lat, lon, sw float64
+		for _, t := range db.routes {
+			for _, pt := range t.pts {
+				d := math.Hypot((pt.Lon-m.Lon)*k, pt.Lat-m.Lat)
+				if d < 1e-12 {
+					// The estimate sits on a waypoint; skip it
+					// rather than divide by zero.
+					continue
+				}
+				lat += pt.Lat / d
+				lon += pt.Lon / d
+				sw += 1 / d
+			}
+		}
+		if sw == 0 {
+			break
+		}
+		next := Stop{lat / sw, lon / sw}
+		done := math.Abs(next.Lat-m.Lat) < 1e-9 && math.Abs(next.Lon-m.Lon) < 1e-9
+		m = next
+		if done {
+			break
+		}
+	}
+	return &Stop{Lat: m.Lat, Lon: m.Lon}, nil
+}
@@ -0,0 +1,44 @@
+package routedb
+
+import "testing"
+
+func TestMedianStop(t *testing.T) {
+	// A route wandering around a small neighbourhood, and a short
+	// route far away pulling the centroid off towards it.
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{
+			40.500, 72.800, 40.501, 72.801, 40.502, 72.800, 40.501, 72.799,
+			40.499, 72.799, 40.498, 72.800, 40.499, 72.801, 40.500, 72.802,
+		}},
+		testRoute{"kg-osh-2", []float64{41.5, 73.8, 41.501, 73.8}},
+	)
+
+	var centroid Stop
+	n := 0
+	for _, r := range db.routes {
+		for _, pt := range r.pts {
+			centroid.Lat += pt.Lat
+			centroid.Lon += pt.Lon
+			n++
+		}
+	}
+	centroid.Lat /= float64(n)
+	centroid.Lon /= float64(n)
+
+	median, err := db.MedianStop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	center := Stop{40.5, 72.8}
+	dm, dc := distance(center, *median), distance(center, centroid)
+	if dm >= dc {
+		t.Errorf("median is %v m from the cluster, centroid %v m", dm, dc)
+	}
+	if dm > 500 {
+		t.Errorf("median %v is %v m from the cluster", *median, dm)
+	}
+
+	if _, err := (&Db{}).MedianStop(); err == nil {
+		t.Error("expected no stop error on empty db")
+	}
+}
@@ -0,0 +1,167 @@
+package routedb
+
+import (
+	"sort"
+	"strings"
+)
+
+// RouteCountByCity returns the number of routes in each city. Routes
+// whose metadata name is not of the form country-city-name are
+// counted under the empty string.
+func (db *Db) RouteCountByCity() map[string]int {
+	counts := make(map[string]int)
+	for _, t := range db.routes {
+		_, city, _ := t.split()
+		counts[city]++
+	}
+	return counts
+}
+
+// RoutesInCity returns the indices of the routes in the given city.
+func (db *Db) RoutesInCity(city string) []int {
+	routes := []int{}
+	for i, t := range db.routes {
+		if _, c, _ := t.split(); c == city {
+			routes = append(routes, i)
+		}
+	}
+	return routes
+}
+
+// Countries returns the distinct countries of the routes, in the
+// canonical form described for CountryCode when they are known, sorted
+// and one per line, so an app can build its region picker from the
+// data. Routes whose metadata name is not of the form
+// country-city-name are left out.
+func (db *Db) Countries() string {
+	seen := make(map[string]bool)
+	for _, t := range db.routes {
+		country, _, _ := t.split()
+		seen[country] = true
+	}
+	return sortedLines(seen)
+}
+
+// Cities returns the distinct cities of the routes in country, given
+// as for CountryCode, sorted and one per line.
+func (db *Db) Cities(country string) string {
+	if c := lookupCountry(country); c != nil {
+		country = c.code
+	}
+	seen := make(map[string]bool)
+	for _, t := range db.routes {
+		if co, city, _ := t.split(); co == country {
+			seen[city] = true
+		}
+	}
+	return sortedLines(seen)
+}
+
+// FilterByCity returns a Db holding only the routes of db in the
+// given city of country, given as for CountryCode, so that the
+// queries on it, its bounds and its indexes cover only that city. The
+// routes are shared with db, not copied, so the new Db is cheap to
+// make, but neither may be changed while the other is in use. It has
+// no warnings, and no saved transfers, and ArchiveWithTransfers fails
+// on it.
+func (db *Db) FilterByCity(country, city string) *Db {
+	if c := lookupCountry(country); c != nil {
+		country = c.code
+	}
+	sub := &Db{noIndex: db.noIndex, locale: db.locale}
+	for _, t := range db.routes {
+		if co, ci, _ := t.split(); co == country && ci == city {
+			sub.routes = append(sub.routes, t)
+		}
+	}
+	sub.RecomputeBounds()
+	return sub
+}
+
+// sortedLines returns the non-empty strings in set, sorted and joined
+// by newlines.
+func sortedLines(set map[string]bool) string {
+	var list []string
+	for s := range set {
+		if s != "" {
+			list = append(list, s)
+		}
+	}
+	sort.Strings(list)
+	return strings.Join(list, "\n")
+}
+
+// Search returns the indices of the routes whose country, city or
+// name contains query, ignoring case. Routes matching by name come
+// first, then those matching by city, then by country. An empty query
+// matches every route.
+func (db *Db) Search(query string) []int {
+	q := strings.ToLower(query)
+	var byName, byCity, byCountry []int
+	for i, t := range db.routes {
+		country, city, name := t.split()
+		switch {
+		case strings.Contains(strings.ToLower(name), q):
+			byName = append(byName, i)
+		case strings.Contains(strings.ToLower(city), q):
+			byCity = append(byCity, i)
+		case strings.Contains(strings.ToLower(country), q):
+			byCountry = append(byCountry, i)
+		}
+	}
+	return append(append(append([]int{}, byName...), byCity...), byCountry...)
+}
+
+// RouteByKey returns the index of the first route whose metadata
+// name, of the form country-city-name, is exactly key.
+func (db *Db) RouteByKey(key string) (int, bool) {
+	for i, t := range db.routes {
+		if t.md == key {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// RouteByKeyFuzzy is like RouteByKey, but tolerates the differences
+// in spelling common between feeds. Both key and the metadata names
+// are normalized before comparing them: they are lower cased, leading
+// and trailing space is trimmed, runs of space are collapsed to a
+// single space, and accents are stripped from Latin letters (so "é"
+// matches "e"), as is the diaeresis from the Cyrillic "ё".
+func (db *Db) RouteByKeyFuzzy(key string) (int, bool) {
+	key = normalizeKey(key)
+	for i, t := range db.routes {
+		if normalizeKey(t.md) == key {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// unaccent maps accented letters to their unaccented form.
+var unaccent = strings.NewReplacer(
+	"à", "a", "á", "a", "â", "a", "ã", "a", "ä", "a", "å", "a", "ā", "a", "ă", "a", "ą", "a",
+	"ç", "c", "ć", "c", "č", "c",
+	"ď", "d",
+	"è", "e", "é", "e", "ê", "e", "ë", "e", "ē", "e", "ė", "e", "ę", "e", "ě", "e",
+	"ğ", "g",
+	"ì", "i", "í", "i", "î", "i", "ï", "i", "ī", "i", "ı", "i",
+	"ł", "l",
+	"ñ", "n", "ń", "n", "ň", "n",
+	"ò", "o", "ó", "o", "ô", "o", "õ", "o", "ö", "o", "ø", "o", "ō", "o", "ő", "o",
+	"ř", "r",
+	"ś", "s", "š", "s", "ş", "s",
+	"ť", "t", "ţ", "t",
+	"ù", "u", "ú", "u", "û", "u", "ü", "u", "ū", "u", "ů", "u", "ű", "u",
+	"ý", "y", "ÿ", "y",
+	"ź", "z", "ż", "z", "ž", "z",
+	"ё", "е",
+)
+
+// normalizeKey returns key normalized as described for
+// RouteByKeyFuzzy.
+func normalizeKey(key string) string {
+	key = strings.Join(strings.Fields(strings.ToLower(key)), " ")
+	return unaccent.Replace(key)
+}
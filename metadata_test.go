@@ -0,0 +1,156 @@
+package routedb
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRouteCountByCity(t *testing.T) {
+	db := loadTestdata(t, "testdata/cities.zip")
+	counts := db.RouteCountByCity()
+	exp := map[string]int{"osh": 2, "bishkek": 1, "": 1}
+	if len(counts) != len(exp) {
+		t.Errorf("got %v, expected %v", counts, exp)
+	}
+	for city, n := range exp {
+		if counts[city] != n {
+			t.Errorf("%q has %v routes, expected %v", city, counts[city], n)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-149", []float64{40.5, 72.8}},
+		testRoute{"kg-bishkek-5", []float64{42.87, 74.59}},
+		testRoute{"kg-bishkek-Osh Bazaar", []float64{42.88, 74.57}},
+	)
+
+	for _, tc := range []struct {
+		query string
+		exp   []int
+	}{
+		{"OSH", []int{2, 0}},
+		{"kg", []int{0, 1, 2}},
+		{"bazaar", []int{2}},
+		{"tokmok", []int{}},
+		{"", []int{0, 1, 2}},
+	} {
+		got := db.Search(tc.query)
+		if len(got) != len(tc.exp) {
+			t.Errorf("Search(%q) is %v, expected %v", tc.query, got, tc.exp)
+			continue
+		}
+		for k := range got {
+			if got[k] != tc.exp[k] {
+				t.Errorf("Search(%q) is %v, expected %v", tc.query, got, tc.exp)
+				break
+			}
+		}
+	}
+}
+
+func TestRoutesInCity(t *testing.T) {
+	db := loadTestdata(t, "testdata/cities.zip")
+	for _, tc := range []struct {
+		city string
+		exp  string
+	}{
+		{"osh", "[0 1]"},
+		{"bishkek", "[2]"},
+		{"tokmok", "[]"},
+	} {
+		if got := fmt.Sprint(db.RoutesInCity(tc.city)); got != tc.exp {
+			t.Errorf("routes in %v are %v, expected %v", tc.city, got, tc.exp)
+		}
+	}
+}
+
+func TestCountriesAndCities(t *testing.T) {
+	sdb := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.8}},
+		testRoute{"KG-bishkek-5", []float64{42.8, 74.6}},
+		testRoute{"kg-osh-2", []float64{40.5, 72.8}},
+		testRoute{"kz-almaty-1", []float64{43.2, 76.9}},
+		testRoute{"unnamed", []float64{43.2, 76.9}},
+	)
+	if c := sdb.Countries(); c != "kg\nkz" {
+		t.Errorf("countries are %q", c)
+	}
+	for _, tc := range []struct {
+		country, exp string
+	}{
+		{"kg", "bishkek\nosh"},
+		{"Kyrgyzstan", "bishkek\nosh"},
+		{"kz", "almaty"},
+		{"uz", ""},
+	} {
+		if got := sdb.Cities(tc.country); got != tc.exp {
+			t.Errorf("cities in %v are %q, expected %q", tc.country, got, tc.exp)
+		}
+	}
+}
+
+func TestFilterByCity(t *testing.T) {
+	sdb := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.8, 40.51, 72.81}},
+		testRoute{"kg-bishkek-5", []float64{42.8, 74.6}},
+		testRoute{"KG-osh-2", []float64{40.52, 72.79}},
+	)
+	osh := sdb.FilterByCity("Kyrgyzstan", "osh")
+	if osh.Routes() != 2 {
+		t.Fatalf("filtered db has %v routes", osh.Routes())
+	}
+	for i, want := range []string{"kg-osh-1", "kg-osh-2"} {
+		if id, _ := osh.RouteID(i); id != want {
+			t.Errorf("route %v is %v, expected %v", i, id, want)
+		}
+	}
+	if b := osh.Bounds(); *b != (Box{N: 40.52, E: 72.81, S: 40.5, W: 72.79}) {
+		t.Errorf("bounds are %+v", b)
+	}
+	// The nearest stop is looked for only in the city.
+	if s, err := osh.Nearest(42.8, 74.6); err != nil || s.Lat != 40.52 {
+		t.Errorf("nearest is %v, %v", s, err)
+	}
+	if osh.routes[0] != sdb.routes[0] {
+		t.Error("routes are not shared")
+	}
+	if _, err := osh.ArchiveWithTransfers(100); err == nil {
+		t.Error("expected error archiving filtered db")
+	}
+
+	if n := sdb.FilterByCity("kg", "tokmok").Routes(); n != 0 {
+		t.Errorf("tokmok has %v routes", n)
+	}
+}
+
+func TestRouteByKey(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-149", []float64{40.5, 72.8}},
+		testRoute{"kg-bishkek-Osh Bazaar", []float64{42.88, 74.57}},
+		testRoute{"fr-paris-Gare de l'Est", []float64{48.87, 2.35}},
+	)
+
+	for _, tc := range []struct {
+		key   string
+		exact int
+		fuzzy int
+	}{
+		{"kg-osh-149", 0, 0},
+		{"kg-bishkek-Osh Bazaar", 1, 1},
+		{"  KG-Bishkek-osh   bazaar ", -1, 1},
+		{"fr-paris-gare de l'est", -1, 2},
+		{"FR-Päris-Garé  de l'Ést", -1, 2},
+		{"kg-osh-150", -1, -1},
+	} {
+		i, ok := db.RouteByKey(tc.key)
+		if i != tc.exact || ok != (tc.exact >= 0) {
+			t.Errorf("RouteByKey(%q) is %v, %v; expected %v", tc.key, i, ok, tc.exact)
+		}
+		i, ok = db.RouteByKeyFuzzy(tc.key)
+		if i != tc.fuzzy || ok != (tc.fuzzy >= 0) {
+			t.Errorf("RouteByKeyFuzzy(%q) is %v, %v; expected %v", tc.key, i, ok, tc.fuzzy)
+		}
+	}
+}
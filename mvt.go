@@ -0,0 +1,188 @@
+package routedb
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	// mvtExtent is the size of a vector tile in tile-local units.
+	mvtExtent = 4096
+
+	// mvtBuffer is how far, in tile-local units, lines are drawn
+	// past the edge of the tile so that strokes join up cleanly with
+	// the neighbouring tiles.
+	mvtBuffer = 64
+)
+
+// VectorTile returns the slippy map tile z/x/y as a Mapbox Vector
+// Tile (version 2). The tile has a single layer named "routes" with
+// one LineString feature per route crossing the tile, carrying the
+// route's country, city and name, in the locale set by SetLocale, as
+// attributes. Routes are clipped
+// to the tile, and projected with Web Mercator into a 4096 unit tile.
+// A tile crossed by no routes is returned as a valid, empty MVT.
+func (db *Db) VectorTile(z, x, y int) ([]byte, error) {
+	if z < 0 || z > 30 {
+		return nil, errors.New("zoom out of range")
+	}
+	n := 1 << uint(z)
+	if x < 0 || x >= n || y < 0 || y >= n {
+		return nil, errors.New("tile out of range")
+	}
+
+	// project returns the tile-local coordinates of s.
+	project := func(s Stop) (float64, float64) {
+		lat := s.Lat * math.Pi / 180
+		wx := (s.Lon + 180) / 360 * float64(n)
+		wy := (1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * float64(n)
+		return (wx - float64(x)) * mvtExtent, (wy - float64(y)) * mvtExtent
+	}
+
+	var features [][]byte
+	var values []string
+	vidx := make(map[string]uint32)
+	value := func(s string) uint32 {
+		if v, ok := vidx[s]; ok {
+			return v
+		}
+		vidx[s] = uint32(len(values))
+		values = append(values, s)
+		return vidx[s]
+	}
+
+	for i, t := range db.routes {
+		lines := clipPath(t.pts, project)
+		if len(lines) == 0 {
+			continue
+		}
+
+		country, city, _ := t.split()
+		tags := []uint32{0, value(country), 1, value(city), 2, value(t.name(db.locale))}
+
+		var geom []uint32
+		var cx, cy int
+		for _, line := range lines {
+			geom = append(geom, mvtCommand(1, 1),
+				zigzag(line[0][0]-cx), zigzag(line[0][1]-cy))
+			cx, cy = line[0][0], line[0][1]
+			geom = append(geom, mvtCommand(2, len(line)-1))
+			for _, p := range line[1:] {
+				geom = append(geom, zigzag(p[0]-cx), zigzag(p[1]-cy))
+				cx, cy = p[0], p[1]
+			}
+		}
+
+		var f []byte
+		f = pbVarint(f, 1, uint64(i))
+		f = pbPacked(f, 2, tags)
+		f = pbVarint(f, 3, 2) // LINESTRING
+		f = pbPacked(f, 4, geom)
+		features = append(features, f)
+	}
+
+	if len(features) == 0 {
+		return []byte{}, nil
+	}
+
+	var layer []byte
+	layer = pbVarint(layer, 15, 2)
+	layer = pbBytes(layer, 1, []byte("routes"))
+	for _, f := range features {
+		layer = pbBytes(layer, 2, f)
+	}
+	for _, k := range []string{"country", "city", "name"} {
+		layer = pbBytes(layer, 3, []byte(k))
+	}
+	for _, v := range values {
+		layer = pbBytes(layer, 4, pbBytes(nil, 1, []byte(v)))
+	}
+	layer = pbVarint(layer, 5, mvtExtent)
+
+	return pbBytes(nil, 3, layer), nil
+}
+
+// clipPath projects pts with project, clips the resulting line to the
+// tile plus its buffer, and returns the parts inside as lines of
+// integer tile coordinates, each with at least two distinct points.
+func clipPath(pts []Stop, project func(Stop) (float64, float64)) [][][2]int {
+	var lines [][][2]int
+	var cur [][2]int
+	flush := func() {
+		if len(cur) >= 2 {
+			lines = append(lines, cur)
+		}
+		cur = nil
+	}
+
+	for j := 1; j < len(pts); j++ {
+		x0, y0 := project(pts[j-1])
+		x1, y1 := project(pts[j])
+		x0, y0, x1, y1, ok := clipSegment(x0, y0, x1, y1, -mvtBuffer, mvtExtent+mvtBuffer)
+		if !ok {
+			flush()
+			continue
+		}
+		a := [2]int{int(math.Floor(x0 + 0.5)), int(math.Floor(y0 + 0.5))}
+		b := [2]int{int(math.Floor(x1 + 0.5)), int(math.Floor(y1 + 0.5))}
+		if len(cur) == 0 || cur[len(cur)-1] != a {
+			flush()
+			cur = append(cur, a)
+		}
+		if cur[len(cur)-1] != b {
+			cur = append(cur, b)
+		}
+	}
+	flush()
+	return lines
+}
+
+// clipSegment clips the segment from (x0, y0) to (x1, y1) to the
+// square [min, max] x [min, max]. It returns the clipped segment, and
+// false if none of it is inside.
+func clipSegment(x0, y0, x1, y1, min, max float64) (float64, float64, float64, float64, bool) {
+	t0, t1, ok := clipRange(x0, y0, x1, y1, min, min, max, max)
+	dx, dy := x1-x0, y1-y0
+	return x0 + t0*dx, y0 + t0*dy, x0 + t1*dx, y0 + t1*dy, ok
+}
+
+// mvtCommand encodes an MVT geometry command with its repeat count.
+func mvtCommand(id, count int) uint32 {
+	return uint32(id&7) | uint32(count)<<3
+}
+
+// zigzag encodes a signed MVT geometry parameter.
+func zigzag(n int) uint32 {
+	return uint32(int32(n)<<1) ^ uint32(int32(n)>>31)
+}
+
+// The pb functions append protocol buffer fields to buf.
+
+func pbKey(buf []byte, field, wire int) []byte {
+	return pbUvarint(buf, uint64(field)<<3|uint64(wire))
+}
+
+func pbUvarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
+
+func pbVarint(buf []byte, field int, v uint64) []byte {
+	return pbUvarint(pbKey(buf, field, 0), v)
+}
+
+func pbBytes(buf []byte, field int, b []byte) []byte {
+	buf = pbUvarint(pbKey(buf, field, 2), uint64(len(b)))
+	return append(buf, b...)
+}
+
+func pbPacked(buf []byte, field int, vs []uint32) []byte {
+	var b []byte
+	for _, v := range vs {
+		b = pbUvarint(b, uint64(v))
+	}
+	return pbBytes(buf, field, b)
+}
@@ -0,0 +1,164 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+// A pbField is one decoded protocol buffer field.
+type pbField struct {
+	num   int
+	v     uint64
+	bytes []byte
+}
+
+// pbDecode decodes the varint and length-delimited fields in buf.
+func pbDecode(t *testing.T, buf []byte) []pbField {
+	uvarint := func() uint64 {
+		var v uint64
+		for shift := uint(0); ; shift += 7 {
+			if len(buf) == 0 {
+				t.Fatal("truncated varint")
+			}
+			b := buf[0]
+			buf = buf[1:]
+			v |= uint64(b&0x7f) << shift
+			if b < 0x80 {
+				return v
+			}
+		}
+	}
+	var fields []pbField
+	for len(buf) > 0 {
+		key := uvarint()
+		f := pbField{num: int(key >> 3)}
+		switch key & 7 {
+		case 0:
+			f.v = uvarint()
+		case 2:
+			n := uvarint()
+			if uint64(len(buf)) < n {
+				t.Fatal("truncated field")
+			}
+			f.bytes, buf = buf[:n], buf[n:]
+		default:
+			t.Fatalf("unexpected wire type %v", key&7)
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// pbUint32s decodes a packed repeated field.
+func pbUint32s(buf []byte) []uint32 {
+	var vs []uint32
+	for len(buf) > 0 {
+		var v uint64
+		for shift := uint(0); ; shift += 7 {
+			b := buf[0]
+			buf = buf[1:]
+			v |= uint64(b&0x7f) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		vs = append(vs, uint32(v))
+	}
+	return vs
+}
+
+// tileFor returns the tile at zoom z containing lat, lon.
+func tileFor(z int, lat, lon float64) (x, y int) {
+	n := float64(int(1) << uint(z))
+	r := lat * math.Pi / 180
+	x = int((lon + 180) / 360 * n)
+	y = int((1 - math.Log(math.Tan(r)+1/math.Cos(r))/math.Pi) / 2 * n)
+	return
+}
+
+func TestVectorTile(t *testing.T) {
+	z := 14
+	x, y := tileFor(z, 40.50263, 72.821976)
+	buf, err := db.VectorTile(z, x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tile := pbDecode(t, buf)
+	if len(tile) != 1 || tile[0].num != 3 {
+		t.Fatalf("expected one layer, got %v", tile)
+	}
+	var name string
+	var keys, values []string
+	var features [][]pbField
+	for _, f := range pbDecode(t, tile[0].bytes) {
+		switch f.num {
+		case 1:
+			name = string(f.bytes)
+		case 2:
+			features = append(features, pbDecode(t, f.bytes))
+		case 3:
+			keys = append(keys, string(f.bytes))
+		case 4:
+			values = append(values, string(pbDecode(t, f.bytes)[0].bytes))
+		}
+	}
+	if name != "routes" {
+		t.Errorf("layer name is %q", name)
+	}
+	if len(features) != 1 {
+		t.Fatalf("got %v features, expected 1", len(features))
+	}
+
+	attrs := make(map[string]string)
+	var geom []uint32
+	for _, f := range features[0] {
+		switch f.num {
+		case 2:
+			tags := pbUint32s(f.bytes)
+			for k := 0; k+1 < len(tags); k += 2 {
+				attrs[keys[tags[k]]] = values[tags[k+1]]
+			}
+		case 3:
+			if f.v != 2 {
+				t.Errorf("geometry type is %v, expected LineString", f.v)
+			}
+		case 4:
+			geom = pbUint32s(f.bytes)
+		}
+	}
+	if attrs["country"] != "kg" || attrs["city"] != "osh" || attrs["name"] != "149" {
+		t.Errorf("unexpected attributes %v", attrs)
+	}
+
+	// Walk the geometry, checking every point lies in the tile or
+	// its buffer.
+	cx, cy := 0, 0
+	for k := 0; k < len(geom); {
+		id, count := geom[k]&7, int(geom[k]>>3)
+		k++
+		if id != 1 && id != 2 {
+			t.Fatalf("unexpected command %v", id)
+		}
+		for ; count > 0; count-- {
+			cx += int(int32(geom[k]>>1) ^ -int32(geom[k]&1))
+			cy += int(int32(geom[k+1]>>1) ^ -int32(geom[k+1]&1))
+			k += 2
+			if cx < -mvtBuffer || cx > mvtExtent+mvtBuffer || cy < -mvtBuffer || cy > mvtExtent+mvtBuffer {
+				t.Errorf("point %v,%v outside tile", cx, cy)
+			}
+		}
+	}
+
+	buf, err = db.VectorTile(z, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pbDecode(t, buf)) != 0 {
+		t.Error("expected empty tile")
+	}
+
+	if _, err := db.VectorTile(1, 2, 0); err == nil {
+		t.Error("expected error for tile out of range")
+	}
+}
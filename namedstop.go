@@ -0,0 +1,251 @@
+package routedb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/rndz/gpx"
+)
+
+// A NamedStop is a stop given in the GPX file of a route by a <wpt>
+// element of type "stop": a place where the vehicle really stops,
+// unlike the waypoints of the track, which only give the shape of its
+// path. The type is required because GPS recorders write waypoints of
+// their own, such as where recording stopped.
+//
+// The ID of a stop is derived from its name and its position rounded
+// to stopIDDegrees, so it survives rebuilding the database from the
+// same or slightly corrected data, and clients can keep it to find the
+// stop again, such as for a user's favourites. Stops of different
+// routes with the same name and rounded position are the same stop,
+// and have the same ID.
+type NamedStop struct {
+	ID       string
+	Lat, Lon float64
+	Name     string // in the locale set by SetLocale
+
+	// Wheelchair tells whether the stop can be used in a wheelchair:
+	// WheelchairUnknown, WheelchairAccessible or
+	// WheelchairInaccessible.
+	Wheelchair int
+}
+
+// A routeStop is a named stop of a track.
+type routeStop struct {
+	id   string
+	pt   Stop
+	name string
+	zone string // fare zone, see Fare
+
+	wheelchair int // see NamedStop.Wheelchair
+
+	names map[string]string // localized names by locale
+}
+
+// stopIDDegrees is the precision to which the position of a stop is
+// rounded for its ID: about 10 m.
+const stopIDDegrees = 1e-4
+
+// stopID returns the ID of the stop called name at pt. It is the FNV-64a
+// hash of the rounded latitude and longitude and the name, in hex.
+func stopID(pt Stop, name string) string {
+	h := fnv.New64a()
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(int64(math.Floor(pt.Lat/stopIDDegrees+0.5))))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(int64(math.Floor(pt.Lon/stopIDDegrees+0.5))))
+	h.Write(buf[:])
+	h.Write([]byte(name))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// isStopType reports whether typ is the type of a <wpt> giving a
+// named stop.
+func isStopType(typ string) bool {
+	return strings.EqualFold(strings.TrimSpace(typ), "stop")
+}
+
+// stopsOf returns the named stops given by the <wpt> elements of g, or
+// nil if there are none.
+func stopsOf(g *gpx.Gpx) []routeStop {
+	var stops []routeStop
+	for _, w := range g.Wpt {
+		if !isStopType(w.Type) {
+			continue
+		}
+		pt := Stop{Lat: w.Lat, Lon: w.Lon}
+		name := strings.TrimSpace(w.Name)
+		stops = append(stops, routeStop{id: stopID(pt, name), pt: pt, name: name})
+	}
+	return stops
+}
+
+// waypoints returns the waypoints of t.
+func waypoints(t *track) []Stop {
+	return t.pts
+}
+
+// boardingPoints returns the places where the vehicle on t can be
+// boarded: its named stops if it has any, and otherwise its waypoints.
+func boardingPoints(t *track) []Stop {
+	if len(t.stops) == 0 {
+		return t.pts
+	}
+	pts := make([]Stop, len(t.stops))
+	for k, s := range t.stops {
+		pts[k] = s.pt
+	}
+	return pts
+}
+
+// boardingPoint returns boardingPoints(t)[k].
+func (t *track) boardingPoint(k int) Stop {
+	if len(t.stops) == 0 {
+		return t.pts[k]
+	}
+	return t.stops[k].pt
+}
+
+// hasNamedStops reports whether any route has named stops.
+func (db *Db) hasNamedStops() bool {
+	for _, t := range db.routes {
+		if len(t.stops) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestBoarding is like nearest, but finds the nearest of the
+// boarding points of each route, as given by boardingPoints. The
+// index k is that of the point in boardingPoints(db.routes[ri]).
+func (db *Db) nearestBoarding(p Stop) (ri, k int, d float64) {
+	return db.nearestBoardingWhere(p, nil)
+}
+
+// nearestBoardingWhere is like nearestBoarding, but only considers the
+// boarding points for which keep returns true. A nil keep considers
+// them all.
+func (db *Db) nearestBoardingWhere(p Stop, keep func(ri, k int) bool) (ri, k int, d float64) {
+	if db.stopIndex != nil {
+		return db.stopIndex.nearest(p, keep)
+	}
+	if db.index != nil && !db.hasNamedStops() {
+		return db.index.nearest(p, keep)
+	}
+	ri, k, d = -1, -1, math.Inf(1)
+	for i, t := range db.routes {
+		for j, pt := range boardingPoints(t) {
+			if keep != nil && !keep(i, j) {
+				continue
+			}
+			if dd := distance(p, pt); dd < d {
+				ri, k, d = i, j, dd
+			}
+		}
+	}
+	return
+}
+
+// RouteNamedStops returns the named stops of route i, in the order
+// they appear in its GPX file. It returns an empty list if the route
+// has none, as is the case for routes made with a Builder.
+func (db *Db) RouteNamedStops(i int) ([]*NamedStop, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	stops := make([]*NamedStop, len(t.stops))
+	for k, s := range t.stops {
+		stops[k] = s.named(db.locale)
+	}
+	return stops, nil
+}
+
+// named returns s as a NamedStop, named in the given locale.
+func (s routeStop) named(locale string) *NamedStop {
+	name := localized(s.names, locale, s.name)
+	return &NamedStop{ID: s.id, Lat: s.pt.Lat, Lon: s.pt.Lon, Name: name, Wheelchair: s.wheelchair}
+}
+
+// A stopEntry is a named stop in the index of stops by ID, with the
+// indices of the routes serving it, in ascending order.
+type stopEntry struct {
+	stop   routeStop
+	routes []int
+}
+
+// stopPassMeters is how close the path of a route must come to a named
+// stop for the route to be taken to pass through it.
+const stopPassMeters = 25
+
+// indexStops rebuilds db.stopsByID, the index of the named stops by
+// ID. A stop is served by the routes listing it among their named
+// stops, and by those whose path passes within stopPassMeters of it.
+func (db *Db) indexStops() {
+	db.stopsByID = make(map[string]*stopEntry)
+	for i, t := range db.routes {
+		for _, s := range t.stops {
+			e := db.stopsByID[s.id]
+			if e == nil {
+				e = &stopEntry{stop: s}
+				db.stopsByID[s.id] = e
+			}
+			if n := len(e.routes); n == 0 || e.routes[n-1] != i {
+				e.routes = append(e.routes, i)
+			}
+		}
+	}
+	for _, e := range db.stopsByID {
+		listed := e.routes
+		e.routes = nil
+		for i, t := range db.routes {
+			if k := sort.SearchInts(listed, i); k < len(listed) && listed[k] == i {
+				e.routes = append(e.routes, i)
+				continue
+			}
+			if len(t.pts) == 0 || boxDistance(e.stop.pt, t.bounds) > stopPassMeters {
+				continue
+			}
+			if _, _, _, d := snap(e.stop.pt, t.pts); d <= stopPassMeters {
+				e.routes = append(e.routes, i)
+			}
+		}
+	}
+}
+
+// StopByID returns the named stop with the given ID, as found in
+// NamedStop.ID.
+func (db *Db) StopByID(id string) (*NamedStop, error) {
+	e := db.stopsByID[id]
+	if e == nil {
+		return nil, errNoStop
+	}
+	return e.stop.named(db.locale), nil
+}
+
+// RoutesAtStopID returns the indices of the routes serving the named
+// stop with the given ID, in ascending order: those stopping at it,
+// and those passing within stopPassMeters of it. It returns an empty
+// list if there is no such stop. The answer is looked up in an index
+// built at load time, so it is cheap enough for every stop screen.
+func (db *Db) RoutesAtStopID(id string) []int {
+	e := db.stopsByID[id]
+	if e == nil {
+		return []int{}
+	}
+	return append([]int{}, e.routes...)
+}
+
+// NamedStopCount returns the number of named stops in all the routes.
+// A stop served by several routes is counted once for each.
+func (db *Db) NamedStopCount() int {
+	n := 0
+	for _, t := range db.routes {
+		n += len(t.stops)
+	}
+	return n
+}
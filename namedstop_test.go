@@ -0,0 +1,199 @@
+package routedb
+
+import (
+	"fmt"
+	"testing"
+)
+
+// stopsGPX is a route with two named stops at its ends and a waypoint
+// which is not a stop, and a route without named stops.
+var stopsGPX = []string{`<gpx>
+<metadata><name>kg-osh-1</name></metadata>
+<wpt lat="40.5" lon="72.8"><name>Bazaar</name><type>stop</type></wpt>
+<wpt lat="40.505" lon="72.805"><name>Recording paused</name></wpt>
+<wpt lat="40.51" lon="72.81"><name>Park</name><type>Stop</type></wpt>
+<trk><trkseg>
+<trkpt lat="40.5" lon="72.8"/>
+<trkpt lat="40.505" lon="72.805"/>
+<trkpt lat="40.51" lon="72.81"/>
+</trkseg></trk>
+</gpx>`, `<gpx>
+<metadata><name>kg-osh-2</name></metadata>
+<trk><trkseg>
+<trkpt lat="40.6" lon="72.9"/>
+<trkpt lat="40.61" lon="72.9"/>
+</trkseg></trk>
+</gpx>`}
+
+func TestRouteNamedStops(t *testing.T) {
+	sdb, err := Load(zipGPX(t, stopsGPX...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stops, err := sdb.RouteNamedStops(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stops) != 2 ||
+		*stops[0] != (NamedStop{stopID(Stop{40.5, 72.8}, "Bazaar"), 40.5, 72.8, "Bazaar", WheelchairUnknown}) ||
+		*stops[1] != (NamedStop{stopID(Stop{40.51, 72.81}, "Park"), 40.51, 72.81, "Park", WheelchairUnknown}) {
+		t.Errorf("named stops are %v", stops)
+	}
+	if stops, err := sdb.RouteNamedStops(1); err != nil || len(stops) != 0 {
+		t.Errorf("route without named stops has %v, %v", stops, err)
+	}
+	if _, err := sdb.RouteNamedStops(2); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if n := sdb.NamedStopCount(); n != 2 {
+		t.Errorf("%v named stops, expected 2", n)
+	}
+	if n := db.NamedStopCount(); n != 0 {
+		t.Errorf("fixture has %v named stops", n)
+	}
+}
+
+func TestNearestPrefersNamedStops(t *testing.T) {
+	for _, opts := range []*LoadOptions{nil, {NoSpatialIndex: true}} {
+		sdb, err := LoadWithOptions(zipGPX(t, stopsGPX...), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range []struct {
+			lat, lon float64
+			want     Stop
+		}{
+			// On the middle waypoint, and the <wpt> which is
+			// not a stop.
+			{40.505, 72.8051, Stop{40.51, 72.81}},
+			{40.501, 72.801, Stop{40.5, 72.8}},
+			// Near the route without named stops.
+			{40.608, 72.9, Stop{40.61, 72.9}},
+		} {
+			s, err := sdb.Nearest(tc.lat, tc.lon)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *s != tc.want {
+				t.Errorf("index %v: nearest to %v, %v is %v, expected %v", opts == nil, tc.lat, tc.lon, *s, tc.want)
+			}
+		}
+	}
+}
+
+func TestJoinRoutesKeepsNamedStops(t *testing.T) {
+	reversed := `<gpx>
+<metadata><name>kg-osh-2</name></metadata>
+<wpt lat="40.52" lon="72.82"><name>Station</name><type>stop</type></wpt>
+<wpt lat="40.515" lon="72.815"><name>School</name><type>stop</type></wpt>
+<trk><trkseg>
+<trkpt lat="40.52" lon="72.82"/>
+<trkpt lat="40.51" lon="72.81"/>
+</trkseg></trk>
+</gpx>`
+	sdb, err := Load(zipGPX(t, stopsGPX[0], reversed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	i, err := sdb.JoinRoutes(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stops, _ := sdb.RouteNamedStops(i)
+	var names []string
+	for _, s := range stops {
+		names = append(names, s.Name)
+	}
+	if len(names) != 4 || names[0] != "Bazaar" || names[1] != "Park" || names[2] != "School" || names[3] != "Station" {
+		t.Errorf("joined stops are %v", names)
+	}
+	if s, _ := sdb.Nearest(40.5199, 72.8199); *s != (Stop{40.52, 72.82}) {
+		t.Errorf("nearest after join is %v", *s)
+	}
+}
+
+func TestStopID(t *testing.T) {
+	a := stopID(Stop{40.5, 72.8}, "Bazaar")
+	for _, tc := range []struct {
+		pt   Stop
+		name string
+		same bool
+	}{
+		{Stop{40.5, 72.8}, "Bazaar", true},
+		{Stop{40.50001, 72.79999}, "Bazaar", true},
+		{Stop{40.5002, 72.8}, "Bazaar", false},
+		{Stop{40.5, 72.8}, "Park", false},
+	} {
+		if b := stopID(tc.pt, tc.name); (a == b) != tc.same {
+			t.Errorf("ID of %v %q is %v, Bazaar's is %v", tc.pt, tc.name, b, a)
+		}
+	}
+	if len(a) != 16 {
+		t.Errorf("ID %q is not 16 hex digits", a)
+	}
+
+	// IDs survive reloading, and find the stop again.
+	for n := 0; n < 2; n++ {
+		sdb, err := Load(zipGPX(t, stopsGPX...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := sdb.StopByID(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Name != "Bazaar" || s.Lat != 40.5 || s.Lon != 72.8 {
+			t.Errorf("stop %v is %v", a, s)
+		}
+		if _, err := sdb.StopByID("nonesuch"); err != errNoStop {
+			t.Errorf("expected no stop, got %v", err)
+		}
+	}
+}
+
+func TestRoutesAtStopID(t *testing.T) {
+	// Route 1 also stops at the Park; route 2 passes the Bazaar
+	// without stopping; route 3 is nowhere near.
+	sdb, err := Load(zipGPX(t, stopsGPX[0], `<gpx>
+<metadata><name>kg-osh-3</name></metadata>
+<wpt lat="40.51" lon="72.81"><name>Park</name><type>stop</type></wpt>
+<trk><trkseg>
+<trkpt lat="40.51" lon="72.81"/>
+<trkpt lat="40.52" lon="72.81"/>
+</trkseg></trk>
+</gpx>`, `<gpx>
+<metadata><name>kg-osh-4</name></metadata>
+<trk><trkseg>
+<trkpt lat="40.4999" lon="72.79"/>
+<trkpt lat="40.4999" lon="72.81"/>
+</trkseg></trk>
+</gpx>`, stopsGPX[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bazaar := stopID(Stop{40.5, 72.8}, "Bazaar")
+	park := stopID(Stop{40.51, 72.81}, "Park")
+	for _, tc := range []struct {
+		id   string
+		want []int
+	}{
+		{bazaar, []int{0, 2}},
+		{park, []int{0, 1}},
+		{"nonesuch", []int{}},
+	} {
+		got := sdb.RoutesAtStopID(tc.id)
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("routes at %v are %v, expected %v", tc.id, got, tc.want)
+		}
+	}
+
+	// The index follows changes to the routes.
+	sdb.routes = sdb.routes[1:]
+	sdb.RecomputeBounds()
+	if got := sdb.RoutesAtStopID(park); fmt.Sprint(got) != "[0]" {
+		t.Errorf("routes at park after removal are %v", got)
+	}
+	if got := sdb.RoutesAtStopID(bazaar); len(got) != 0 {
+		t.Errorf("routes at removed stop are %v", got)
+	}
+}
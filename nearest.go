@@ -0,0 +1,432 @@
+package routedb
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/flatbuffers/go"
+	"github.com/jeffallen/routedb/route"
+)
+
+// nearest returns the route and point indices of the waypoint nearest
+// to p, and its distance in meters. If there are no waypoints, the
+// indices are -1.
+func (db *Db) nearest(p Stop) (ri, pi int, d float64) {
+	return db.nearestWhere(p, nil)
+}
+
+// nearestWhere is like nearest, but only considers the routes for
+// which keep returns true. A nil keep considers every route.
+func (db *Db) nearestWhere(p Stop, keep func(i int) bool) (ri, pi int, d float64) {
+	if db.index != nil {
+		if keep == nil {
+			return db.index.nearest(p, nil)
+		}
+		return db.index.nearest(p, func(ri, pi int) bool { return keep(ri) })
+	}
+	return db.nearestScan(p, keep)
+}
+
+// nearestScan is nearestWhere without the spatial index, measuring the
+// distance to every waypoint.
+func (db *Db) nearestScan(p Stop, keep func(i int) bool) (ri, pi int, d float64) {
+	ri, pi, d = -1, -1, math.Inf(1)
+	for i, t := range db.routes {
+		if keep != nil && !keep(i) {
+			continue
+		}
+		for j, pt := range t.pts {
+			if dd := distance(p, pt); dd < d {
+				ri, pi, d = i, j, dd
+			}
+		}
+	}
+	return
+}
+
+// nearestN returns the n waypoints nearest to p, nearest first, with
+// waypoints at the same place counted once. Waypoints at the same
+// distance are ranked by route and then point index, and of waypoints
+// at the same place the first in that order is returned.
+func (db *Db) nearestN(p Stop, n int) []hit {
+	if n <= 0 {
+		return nil
+	}
+	if db.index != nil {
+		return db.index.nearestN(p, n)
+	}
+	var hits []hit
+	for i, t := range db.routes {
+		for j, pt := range t.pts {
+			hits = append(hits, hit{pt, i, j, distance(p, pt)})
+		}
+	}
+	sort.Sort(byRank(hits))
+	hits = distinct(hits)
+	if len(hits) > n {
+		hits = hits[:n]
+	}
+	return hits
+}
+
+// distinct returns the hits, which are in rank order, without those
+// at the same place as an earlier one.
+func distinct(hits []hit) []hit {
+	seen := make(map[Stop]bool)
+	out := []hit{}
+	for _, h := range hits {
+		if !seen[h.pt] {
+			seen[h.pt] = true
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// byRank sorts hits in rank order.
+type byRank []hit
+
+func (s byRank) Len() int           { return len(s) }
+func (s byRank) Less(i, j int) bool { return s[i].before(s[j]) }
+func (s byRank) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// NearestN returns up to n stops nearest to the given point, nearest
+// first, as a FlatBuffer holding a StopList, whose stops are encoded
+// as in Route, each with its distance in meters. Waypoints of several
+// routes at the same place are listed once.
+func (db *Db) NearestN(lat, lon float64, n int) ([]byte, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	hits := db.nearestN(Stop{lat, lon}, n)
+	if len(hits) == 0 && n > 0 {
+		return nil, errNoStop
+	}
+	return finishStopList(hits), nil
+}
+
+// finishStopList returns a finished FlatBuffer holding a StopList of
+// hits.
+func finishStopList(hits []hit) []byte {
+	b := flatbuffers.NewBuilder(0)
+	route.StopListStartMetersVector(b, len(hits))
+	for k := len(hits) - 1; k >= 0; k-- {
+		b.PrependFloat32(float32(hits[k].d))
+	}
+	meters := b.EndVector(len(hits))
+	route.StopListStartStopsVector(b, len(hits))
+	for k := len(hits) - 1; k >= 0; k-- {
+		route.CreateGeoPoint(b, micro(hits[k].pt.Lat), micro(hits[k].pt.Lon))
+	}
+	stops := b.EndVector(len(hits))
+
+	route.StopListStart(b)
+	route.StopListAddStops(b, stops)
+	route.StopListAddMeters(b, meters)
+	b.Finish(route.StopListEnd(b))
+	return b.Bytes[b.Head():]
+}
+
+// within returns the waypoints within meters of p, nearest first, with
+// waypoints at the same place counted once, ranked as by nearestN.
+func (db *Db) within(p Stop, meters float64) []hit {
+	hits := db.withinAll(p, meters)
+	sort.Sort(byRank(hits))
+	return distinct(hits)
+}
+
+// withinAll returns every waypoint within meters of p, including those
+// at the same place, in no particular order.
+func (db *Db) withinAll(p Stop, meters float64) []hit {
+	if db.index != nil {
+		return db.index.within(p, meters)
+	}
+	var hits []hit
+	for i, t := range db.routes {
+		for j, pt := range t.pts {
+			if d := distance(p, pt); d <= meters {
+				hits = append(hits, hit{pt, i, j, d})
+			}
+		}
+	}
+	return hits
+}
+
+// StopsWithinRadius returns the stops within meters of the given
+// point, nearest first, as a FlatBuffer holding a StopList as for
+// NearestN. Waypoints of several routes at the same place are listed
+// once.
+func (db *Db) StopsWithinRadius(lat, lon float64, meters float64) ([]byte, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	return finishStopList(db.within(Stop{lat, lon}, meters)), nil
+}
+
+// NearestDense is like Nearest, but always searches the waypoints,
+// even of routes with named stops, and also considers the midpoints of
+// the two segments on either side of the nearest waypoint, returning
+// whichever of the three is closest. On routes with sparse waypoints
+// this approximates snapping to the path much more cheaply than a
+// full projection onto every segment, though the result is only
+// exact when the closest point on the path happens to be one of the
+// candidates.
+func (db *Db) NearestDense(lat, lon float64) (*Stop, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	p := Stop{lat, lon}
+	ri, pi, d := db.nearest(p)
+	if ri < 0 {
+		return nil, errNoStop
+	}
+
+	pts := db.routes[ri].pts
+	best := pts[pi]
+	for _, k := range []int{pi - 1, pi + 1} {
+		if k < 0 || k >= len(pts) {
+			continue
+		}
+		mid := midpoint(pts[pi], pts[k])
+		if dd := distance(p, mid); dd < d {
+			best, d = mid, dd
+		}
+	}
+	return &Stop{Lat: best.Lat, Lon: best.Lon}, nil
+}
+
+// NearestContext is like Nearest, but always searches the waypoints,
+// even of routes with named stops, and also returns the waypoints
+// before and after the nearest one on its route, and the index of the
+// route. At the ends of a route, prev or next is nil.
+func (db *Db) NearestContext(lat, lon float64) (prev, here, next *Stop, routeIndex int, err error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, nil, nil, -1, err
+	}
+	ri, pi, _ := db.nearest(Stop{lat, lon})
+	if ri < 0 {
+		return nil, nil, nil, -1, errNoStop
+	}
+
+	pts := db.routes[ri].pts
+	here = &Stop{Lat: pts[pi].Lat, Lon: pts[pi].Lon}
+	if pi > 0 {
+		prev = &Stop{Lat: pts[pi-1].Lat, Lon: pts[pi-1].Lon}
+	}
+	if pi+1 < len(pts) {
+		next = &Stop{Lat: pts[pi+1].Lat, Lon: pts[pi+1].Lon}
+	}
+	return prev, here, next, ri, nil
+}
+
+// NearestInCity is like Nearest, but only considers the routes in the
+// given city.
+func (db *Db) NearestInCity(lat, lon float64, city string) (*Stop, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	ri, k, _ := db.nearestBoardingWhere(Stop{lat, lon}, func(i, _ int) bool {
+		_, c, _ := db.routes[i].split()
+		return c == city
+	})
+	if ri < 0 {
+		return nil, errNoStop
+	}
+	pt := db.routes[ri].boardingPoint(k)
+	return &Stop{Lat: pt.Lat, Lon: pt.Lon}, nil
+}
+
+// NearestPreferRoute is like Nearest, but favours the boarding points
+// of route preferIndex, treating them as biasMeters closer than they
+// really are, so that they win ties and near ties. It returns the
+// stop chosen and the index of its route. A preferIndex matching no
+// route gives no preference.
+func (db *Db) NearestPreferRoute(lat, lon float64, preferIndex int, biasMeters float64) (*Stop, int, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, -1, err
+	}
+	p := Stop{lat, lon}
+	ri, pi, best := -1, -1, math.Inf(1)
+	for i, t := range db.routes {
+		for j, pt := range boardingPoints(t) {
+			d := distance(p, pt)
+			if i == preferIndex {
+				d -= biasMeters
+			}
+			if d < best {
+				ri, pi, best = i, j, d
+			}
+		}
+	}
+	if ri < 0 {
+		return nil, -1, errNoStop
+	}
+	pt := db.routes[ri].boardingPoint(pi)
+	return &Stop{Lat: pt.Lat, Lon: pt.Lon}, ri, nil
+}
+
+// NearestPerRoute returns, for each route with a waypoint within
+// maxMeters of lat, lon, the waypoint of that route nearest to it,
+// keyed by route index. Invalid positions give an empty map.
+func (db *Db) NearestPerRoute(lat, lon float64, maxMeters float64) map[int]*Stop {
+	stops := make(map[int]*Stop)
+	if checkLatLon(lat, lon) != nil {
+		return stops
+	}
+	p := Stop{lat, lon}
+	for i, t := range db.routes {
+		best := maxMeters
+		for _, pt := range t.pts {
+			if d := distance(p, pt); d <= best {
+				best = d
+				stops[i] = &Stop{Lat: pt.Lat, Lon: pt.Lon}
+			}
+		}
+	}
+	return stops
+}
+
+// NearestSegment returns the route and segment index of the line
+// segment nearest to the given point, and the perpendicular distance
+// in meters to it. Segment j runs from waypoint j to waypoint j+1.
+// Routes with fewer than two waypoints have no segments and are
+// skipped.
+func (db *Db) NearestSegment(lat, lon float64) (routeIndex, segIndex int, dist float64, err error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return -1, -1, 0, err
+	}
+	ri, seg, _, d := db.snapToRoutes(Stop{lat, lon}, 2)
+	if ri < 0 {
+		return -1, -1, 0, errNoStop
+	}
+	return ri, seg, d, nil
+}
+
+// snapToRoutes returns the route, the segment and the point of the
+// path nearest to p, and the distance to it in meters, considering
+// only routes with at least minPoints waypoints, which must be at
+// least 1. If there are none, the route index is -1.
+func (db *Db) snapToRoutes(p Stop, minPoints int) (ri, seg int, q Stop, d float64) {
+	ri, seg, d = -1, -1, math.Inf(1)
+	for i, t := range db.routes {
+		// The box around a route is quicker to measure to than
+		// its path, and no further away.
+		if len(t.pts) < minPoints || boxDistance(p, t.bounds) > d {
+			continue
+		}
+		if qq, s, _, dd := snap(p, t.pts); dd < d {
+			ri, seg, q, d = i, s, qq, dd
+		}
+	}
+	return
+}
+
+// NearestRoute returns the index of the route whose path passes
+// nearest to the given point, and the point on the path nearest to
+// it, which usually lies between two waypoints.
+func (db *Db) NearestRoute(lat, lon float64) (routeIndex int, onRoute *Stop, err error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return -1, nil, err
+	}
+	ri, _, q, _ := db.snapToRoutes(Stop{lat, lon}, 1)
+	if ri < 0 {
+		return -1, nil, errNoStop
+	}
+	return ri, &Stop{Lat: q.Lat, Lon: q.Lon}, nil
+}
+
+// A LineHit is a route near a point, with its waypoint nearest to the
+// point and the distance to it in meters.
+type LineHit struct {
+	RouteIndex int
+	Stop       *Stop
+	Meters     float64
+}
+
+// byMeters sorts LineHits by ascending distance.
+type byMeters []LineHit
+
+func (s byMeters) Len() int           { return len(s) }
+func (s byMeters) Less(i, j int) bool { return s[i].Meters < s[j].Meters }
+func (s byMeters) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// NearbyLines returns up to limit routes with a waypoint within
+// maxMeters of lat, lon, nearest first, each with its waypoint nearest
+// to the point: all a "lines near me" screen needs. Routes at the same
+// distance are in index order. A limit of 0 or less means no limit.
+// Invalid positions give no routes.
+func (db *Db) NearbyLines(lat, lon float64, maxMeters float64, limit int) []LineHit {
+	hits := []LineHit{}
+	if checkLatLon(lat, lon) != nil {
+		return hits
+	}
+	p := Stop{lat, lon}
+	for i, t := range db.routes {
+		var best *LineHit
+		for _, pt := range t.pts {
+			if d := distance(p, pt); d <= maxMeters && (best == nil || d < best.Meters) {
+				best = &LineHit{i, &Stop{Lat: pt.Lat, Lon: pt.Lon}, d}
+			}
+		}
+		if best != nil {
+			hits = append(hits, *best)
+		}
+	}
+	sort.Stable(byMeters(hits))
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// RoutesNear returns the routes whose paths come within meters of the
+// given point, nearest first, each with the closest point of its path
+// to the given one and the distance to it. Unlike NearbyLines, this
+// measures to the segments between waypoints, so it finds a route
+// passing close by between two distant waypoints. Invalid positions
+// give no routes.
+func (db *Db) RoutesNear(lat, lon float64, meters float64) []LineHit {
+	hits := []LineHit{}
+	if checkLatLon(lat, lon) != nil {
+		return hits
+	}
+	p := Stop{lat, lon}
+	for i, t := range db.routes {
+		if len(t.pts) == 0 || boxDistance(p, t.bounds) > meters {
+			continue
+		}
+		if q, _, _, d := snap(p, t.pts); d <= meters {
+			hits = append(hits, LineHit{i, &Stop{Lat: q.Lat, Lon: q.Lon}, d})
+		}
+	}
+	sort.Stable(byMeters(hits))
+	return hits
+}
+
+// A NearestResult is the waypoint nearest to a point, as found by
+// NearestDetailed: where it is, which route and point it is, and how
+// far away it is in meters.
+type NearestResult struct {
+	Lat, Lon   float64
+	RouteIndex int
+	PointIndex int
+	Meters     float64
+}
+
+// NearestDetailed is like Nearest, but always searches the waypoints,
+// even of routes with named stops, and also says which waypoint of
+// which route was found, and how far it is from the given point,
+// saving a scan of the routes to find out. Of waypoints shared by
+// several routes, the one on the route with the lowest index is
+// returned.
+func (db *Db) NearestDetailed(lat, lon float64) (*NearestResult, error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	ri, pi, d := db.nearest(Stop{lat, lon})
+	if ri < 0 {
+		return nil, errNoStop
+	}
+	pt := db.routes[ri].pts[pi]
+	return &NearestResult{pt.Lat, pt.Lon, ri, pi, d}, nil
+}
@@ -0,0 +1,398 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestNearestDense(t *testing.T) {
+	db := makeDb(t, testRoute{"kg-osh-sparse", []float64{40.5, 72.80, 40.5, 72.82}})
+
+	lat, lon := 40.5001, 72.81
+	n, err := db.Nearest(lat, lon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dn, err := db.NearestDense(lat, lon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := Stop{lat, lon}
+	if distance(q, *dn) >= distance(q, *n) {
+		t.Errorf("midpoint %v is not closer than waypoint %v", *dn, *n)
+	}
+	if d := distance(*dn, Stop{40.5, 72.81}); d > 1 {
+		t.Errorf("expected the midpoint, got %v (%v m away)", *dn, d)
+	}
+
+	if _, err := (&Db{}).NearestDense(lat, lon); err == nil {
+		t.Error("expected no stop error on empty db")
+	}
+}
+
+func TestNearestContext(t *testing.T) {
+	prev, here, next, ri, err := db.NearestContext(40.50265, 72.821978)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ri != 0 {
+		t.Errorf("route is %v", ri)
+	}
+	if *here != (Stop{40.50263, 72.821976}) {
+		t.Errorf("here is %v", *here)
+	}
+	pts := db.routes[0].pts
+	j := 0
+	for pts[j] != *here {
+		j++
+	}
+	if j == 0 || j == len(pts)-1 {
+		t.Fatalf("expected a mid-route point, got index %v", j)
+	}
+	if prev == nil || *prev != pts[j-1] || next == nil || *next != pts[j+1] {
+		t.Errorf("neighbours are %v and %v, expected %v and %v", prev, next, pts[j-1], pts[j+1])
+	}
+
+	db := makeDb(t, testRoute{"kg-osh-1", []float64{40.5, 72.8, 40.51, 72.81}})
+	prev, here, next, _, err = db.NearestContext(40.499, 72.799)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev != nil || *here != (Stop{40.5, 72.8}) || next == nil || *next != (Stop{40.51, 72.81}) {
+		t.Errorf("at the start got %v, %v, %v", prev, here, next)
+	}
+
+	if _, _, _, _, err := (&Db{}).NearestContext(40.5, 72.8); err == nil {
+		t.Error("expected no stop error on empty db")
+	}
+}
+
+func TestNearestInCity(t *testing.T) {
+	db := loadTestdata(t, "testdata/cities.zip")
+
+	// Close to Bishkek, but restricted to Osh.
+	n, err := db.NearestInCity(42.87, 74.59, "osh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *n != (Stop{40.53, 72.78}) {
+		t.Errorf("nearest in osh is %v", *n)
+	}
+
+	n, err = db.NearestInCity(40.5, 72.8, "bishkek")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *n != (Stop{42.87, 74.59}) {
+		t.Errorf("nearest in bishkek is %v", *n)
+	}
+
+	if _, err := db.NearestInCity(40.5, 72.8, "tokmok"); err == nil {
+		t.Error("expected no stop error for city without routes")
+	}
+}
+
+func TestNearestPreferRoute(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81}},
+		testRoute{"kg-osh-2", []float64{40.501, 72.80, 40.501, 72.81}},
+	)
+
+	// 40 m from route 0, 71 m from route 1.
+	lat, lon := 40.50036, 72.80
+	for _, tc := range []struct {
+		prefer int
+		bias   float64
+		exp    int
+	}{
+		{1, 0, 0},
+		{1, 20, 0},
+		{1, 50, 1},
+		{0, 50, 0},
+		{5, 50, 0},
+	} {
+		s, ri, err := db.NearestPreferRoute(lat, lon, tc.prefer, tc.bias)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ri != tc.exp || s.Lat != db.routes[tc.exp].pts[0].Lat {
+			t.Errorf("preferring %v by %v m chose %v on route %v", tc.prefer, tc.bias, *s, ri)
+		}
+	}
+
+	if _, _, err := (&Db{}).NearestPreferRoute(lat, lon, 0, 50); err == nil {
+		t.Error("expected no stop error on empty db")
+	}
+}
+
+func TestNearestPerRoute(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.800, 40.5, 72.801, 40.5, 72.802}},
+		testRoute{"kg-osh-2", []float64{40.5005, 72.800, 40.5005, 72.801, 40.5005, 72.802}},
+		testRoute{"kg-osh-3", []float64{40.6, 72.9, 40.61, 72.91}},
+	)
+
+	stops := db.NearestPerRoute(40.5002, 72.8011, 200)
+	if len(stops) != 2 {
+		t.Fatalf("got %v routes, expected 2: %v", len(stops), stops)
+	}
+	if s := stops[0]; s == nil || *s != (Stop{40.5, 72.801}) {
+		t.Errorf("nearest on route 0 is %v", s)
+	}
+	if s := stops[1]; s == nil || *s != (Stop{40.5005, 72.801}) {
+		t.Errorf("nearest on route 1 is %v", s)
+	}
+
+	if stops := db.NearestPerRoute(40.5002, 72.8011, 10); len(stops) != 0 {
+		t.Errorf("expected nothing within 10 m, got %v", stops)
+	}
+}
+
+func TestNearestSegment(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-long", []float64{40.5, 72.80, 40.5, 72.82}},
+		testRoute{"kg-osh-north", []float64{40.5015, 72.81, 40.51, 72.81}},
+	)
+
+	// The nearest waypoint is the start of kg-osh-north, but the
+	// long segment of kg-osh-long passes closer.
+	ri, seg, d, err := db.NearestSegment(40.5005, 72.81)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ri != 0 || seg != 0 {
+		t.Errorf("nearest segment is %v of route %v, expected 0 of route 0", seg, ri)
+	}
+	if d < 50 || d > 60 {
+		t.Errorf("distance %v, expected about 55 meters", d)
+	}
+
+	ri, seg, _, err = db.NearestSegment(40.509, 72.8101)
+	if err != nil || ri != 1 || seg != 0 {
+		t.Errorf("nearest segment is %v of route %v, %v", seg, ri, err)
+	}
+
+	if _, _, _, err := (&Db{}).NearestSegment(40.5, 72.8); err != errNoStop {
+		t.Errorf("expected no stop error, got %v", err)
+	}
+	if _, _, _, err := db.NearestSegment(91, 0); err == nil {
+		t.Error("expected error for invalid latitude")
+	}
+}
+
+func TestNearbyLines(t *testing.T) {
+	// Routes coming within about 330, 110, 220 and 2200 meters north of
+	// the query.
+	db := makeDb(t,
+		testRoute{"kg-osh-3", []float64{40.503, 72.80, 40.504, 72.80}},
+		testRoute{"kg-osh-1", []float64{40.502, 72.80, 40.501, 72.80}},
+		testRoute{"kg-osh-2", []float64{40.502, 72.80, 40.503, 72.81}},
+		testRoute{"kg-osh-far", []float64{40.52, 72.80}},
+	)
+
+	hits := db.NearbyLines(40.5, 72.80, 500, 0)
+	want := []int{1, 2, 0}
+	if len(hits) != len(want) {
+		t.Fatalf("got %v hits, expected %v", len(hits), len(want))
+	}
+	for k, h := range hits {
+		if h.RouteIndex != want[k] {
+			t.Errorf("hit %v is route %v, expected %v", k, h.RouteIndex, want[k])
+		}
+		if d := distance(Stop{40.5, 72.80}, *h.Stop); d != h.Meters {
+			t.Errorf("hit %v is %v meters, reported %v", k, d, h.Meters)
+		}
+	}
+	if hits[0].Meters < 100 || hits[0].Meters > 120 {
+		t.Errorf("nearest hit %v meters away, expected about 110", hits[0].Meters)
+	}
+
+	if hits := db.NearbyLines(40.5, 72.80, 500, 2); len(hits) != 2 || hits[1].RouteIndex != 2 {
+		t.Errorf("limited hits are %v", hits)
+	}
+	if hits := db.NearbyLines(40.5, 72.80, 50, 2); hits == nil || len(hits) != 0 {
+		t.Errorf("expected empty hits, got %v", hits)
+	}
+	if hits := db.NearbyLines(100, 72.80, 500, 2); len(hits) != 0 {
+		t.Errorf("invalid position gave %v", hits)
+	}
+}
+
+func TestNearestN(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.503, 72.80, 40.501, 72.80, 40.502, 72.80}},
+		// Shares a stop with kg-osh-1.
+		testRoute{"kg-osh-2", []float64{40.501, 72.80, 40.504, 72.80}},
+	)
+	for _, index := range []bool{true, false} {
+		if !index {
+			db.index = nil
+		}
+		buf, err := db.NearestN(40.5, 72.80, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := route.GetRootAsStopList(buf, 0)
+		want := []float64{40.501, 40.502, 40.503}
+		if list.StopsLength() != len(want) || list.MetersLength() != len(want) {
+			t.Fatalf("got %v stops and %v distances, expected %v", list.StopsLength(), list.MetersLength(), len(want))
+		}
+		var pt route.GeoPoint
+		for j, lat := range want {
+			list.Stops(&pt, j)
+			if pt.Lat() != micro(lat) || pt.Lon() != micro(72.80) {
+				t.Errorf("stop %v is %v, %v, expected %v", j, pt.Lat(), pt.Lon(), micro(lat))
+			}
+			d := distance(Stop{40.5, 72.80}, Stop{lat, 72.80})
+			if m := float64(list.Meters(j)); math.Abs(m-d) > 0.01 {
+				t.Errorf("stop %v is %v meters away, expected %v", j, m, d)
+			}
+		}
+
+		buf, err = db.NearestN(40.5, 72.80, 10)
+		if err != nil || route.GetRootAsStopList(buf, 0).StopsLength() != 4 {
+			t.Errorf("expected all 4 distinct stops, %v", err)
+		}
+	}
+
+	if _, err := (&Db{}).NearestN(40.5, 72.8, 3); err != errNoStop {
+		t.Errorf("expected no stop error, got %v", err)
+	}
+	if _, err := db.NearestN(-91, 72.8, 3); err == nil {
+		t.Error("expected error for invalid latitude")
+	}
+	if buf, err := db.NearestN(40.5, 72.8, 0); err != nil || route.GetRootAsStopList(buf, 0).StopsLength() != 0 {
+		t.Errorf("expected no stops, %v", err)
+	}
+}
+
+func TestStopsWithinRadius(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.503, 72.80, 40.501, 72.80, 40.502, 72.80}},
+		testRoute{"kg-osh-2", []float64{40.501, 72.80, 40.504, 72.80, 40.499, 72.80}},
+	)
+	for _, index := range []bool{true, false} {
+		if !index {
+			db.index = nil
+		}
+		// 40.499, 40.501 and 40.502 are within 250 m.
+		buf, err := db.StopsWithinRadius(40.5, 72.80, 250)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list := route.GetRootAsStopList(buf, 0)
+		if list.StopsLength() != 3 {
+			t.Fatalf("got %v stops, expected 3", list.StopsLength())
+		}
+		var pt route.GeoPoint
+		for j, lat := range []float64{40.499, 40.501, 40.502} {
+			list.Stops(&pt, j)
+			// 40.499 and 40.501 are the same distance away,
+			// and ranked by route index.
+			if j < 2 && pt.Lat() != micro(40.499) && pt.Lat() != micro(40.501) || j == 2 && pt.Lat() != micro(lat) {
+				t.Errorf("stop %v is at %v, expected %v", j, pt.Lat(), micro(lat))
+			}
+			if m := list.Meters(j); m > 250 {
+				t.Errorf("stop %v is %v meters away", j, m)
+			}
+		}
+
+		buf, err = db.StopsWithinRadius(40.5, 72.80, 50)
+		if err != nil || route.GetRootAsStopList(buf, 0).StopsLength() != 0 {
+			t.Errorf("expected no stops, %v", err)
+		}
+	}
+	if _, err := db.StopsWithinRadius(40.5, 181, 250); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
+
+func TestRoutesNear(t *testing.T) {
+	db := makeDb(t,
+		// Passes 55 meters north of the query between waypoints
+		// a kilometer away.
+		testRoute{"kg-osh-long", []float64{40.5005, 72.79, 40.5005, 72.81}},
+		testRoute{"kg-osh-near", []float64{40.5002, 72.80}},
+		testRoute{"kg-osh-far", []float64{40.51, 72.79, 40.51, 72.81}},
+	)
+	hits := db.RoutesNear(40.5, 72.80, 100)
+	if len(hits) != 2 {
+		t.Fatalf("got %v routes, expected 2", len(hits))
+	}
+	if hits[0].RouteIndex != 1 || hits[1].RouteIndex != 0 {
+		t.Errorf("routes are %v and %v, expected 1 and 0", hits[0].RouteIndex, hits[1].RouteIndex)
+	}
+	if d := hits[1].Meters; d < 50 || d > 60 {
+		t.Errorf("closest approach %v, expected about 55 meters", d)
+	}
+	if s := hits[1].Stop; math.Abs(s.Lat-40.5005) > 1e-6 || math.Abs(s.Lon-72.80) > 1e-6 {
+		t.Errorf("closest point %v, expected 40.5005, 72.80", *s)
+	}
+	if hits := db.NearbyLines(40.5, 72.80, 100, 0); len(hits) != 1 {
+		t.Errorf("NearbyLines found %v routes, expected only the near one", len(hits))
+	}
+	if hits := db.RoutesNear(40.5, 72.80, 10); hits == nil || len(hits) != 0 {
+		t.Errorf("expected no routes, got %v", hits)
+	}
+}
+
+func TestNearestRoute(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-long", []float64{40.5005, 72.79, 40.5005, 72.81}},
+		testRoute{"kg-osh-stop", []float64{40.5001, 72.803}},
+	)
+	ri, q, err := db.NearestRoute(40.5, 72.80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ri != 0 {
+		t.Errorf("nearest route %v, expected 0", ri)
+	}
+	if math.Abs(q.Lat-40.5005) > 1e-6 || math.Abs(q.Lon-72.80) > 1e-6 {
+		t.Errorf("projection %v, expected 40.5005, 72.80", *q)
+	}
+
+	ri, q, err = db.NearestRoute(40.5, 72.803)
+	if err != nil || ri != 1 || *q != (Stop{40.5001, 72.803}) {
+		t.Errorf("nearest route %v at %v, %v, expected the single stop", ri, q, err)
+	}
+
+	if _, _, err := (&Db{}).NearestRoute(40.5, 72.8); err != errNoStop {
+		t.Errorf("expected no stop error, got %v", err)
+	}
+	if _, _, err := db.NearestRoute(math.NaN(), 72.8); err == nil {
+		t.Error("expected error for invalid latitude")
+	}
+}
+
+func TestNearestDetailed(t *testing.T) {
+	r, err := db.NearestDetailed(40.50263, 72.821976)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt := db.routes[r.RouteIndex].pts[r.PointIndex]
+	if pt != (Stop{40.50263, 72.821976}) || r.Lat != pt.Lat || r.Lon != pt.Lon || r.Meters != 0 {
+		t.Errorf("unexpected result %+v", *r)
+	}
+
+	sdb := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.51, 72.80}},
+		testRoute{"kg-osh-2", []float64{40.52, 72.80, 40.51, 72.80}},
+	)
+	r, err = sdb.NearestDetailed(40.5105, 72.80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.RouteIndex != 0 || r.PointIndex != 1 || math.Abs(r.Meters-55.6) > 0.1 {
+		t.Errorf("unexpected result %+v", *r)
+	}
+
+	if _, err := (&Db{}).NearestDetailed(40.5, 72.8); err != errNoStop {
+		t.Errorf("expected no stop error, got %v", err)
+	}
+	if _, err := sdb.NearestDetailed(40.5, -200); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
@@ -0,0 +1,164 @@
+package routedb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// EndpointDistanceMatrix returns the great circle distances in meters
+// between the ends of every pair of routes, as a 2N by 2N matrix for
+// N routes. Row and column 2i are the start of route i, and 2i+1 its
+// end. Distances involving a route with no waypoints are NaN.
+func (db *Db) EndpointDistanceMatrix() [][]float64 {
+	n := 2 * len(db.routes)
+	ends := make([]*Stop, n)
+	for i, t := range db.routes {
+		if len(t.pts) > 0 {
+			ends[2*i] = &t.pts[0]
+			ends[2*i+1] = &t.pts[len(t.pts)-1]
+		}
+	}
+
+	m := make([][]float64, n)
+	for a := range m {
+		m[a] = make([]float64, n)
+	}
+	for a := 0; a < n; a++ {
+		for b := a; b < n; b++ {
+			d := math.NaN()
+			if ends[a] != nil && ends[b] != nil {
+				d = distance(*ends[a], *ends[b])
+			}
+			m[a][b], m[b][a] = d, d
+		}
+	}
+	return m
+}
+
+// AreContinuous reports whether routes i and j meet end to end: that
+// is, whether either end of route i is within toleranceMeters of
+// either end of route j. Such pairs, like the inbound and outbound
+// halves of a line, can be joined into one through-route.
+func (db *Db) AreContinuous(i, j int, toleranceMeters float64) (bool, error) {
+	a, err := db.routeAt(i)
+	if err != nil {
+		return false, err
+	}
+	b, err := db.routeAt(j)
+	if err != nil {
+		return false, err
+	}
+	_, _, d := closestEnds(a.pts, b.pts)
+	return d <= toleranceMeters, nil
+}
+
+// closestEnds returns which ends of a and b are closest together,
+// each as false for the start and true for the end, and the distance
+// between them in meters. If either path is empty the distance is
+// infinite.
+func closestEnds(a, b []Stop) (aEnd, bEnd bool, d float64) {
+	d = math.Inf(1)
+	if len(a) == 0 || len(b) == 0 {
+		return
+	}
+	for _, ae := range []bool{false, true} {
+		for _, be := range []bool{false, true} {
+			pa, pb := a[0], b[0]
+			if ae {
+				pa = a[len(a)-1]
+			}
+			if be {
+				pb = b[len(b)-1]
+			}
+			if dd := distance(pa, pb); dd < d {
+				aEnd, bEnd, d = ae, be, dd
+			}
+		}
+	}
+	return
+}
+
+// joinMeters is how close the ends of two routes must be for
+// JoinRoutes to join them.
+const joinMeters = 50
+
+// JoinRoutes joins route j onto route i, making one through-route
+// which keeps the metadata of route i. The ends of the routes which
+// are closest together must be within joinMeters; route j is reversed
+// if need be so that they meet. Route j is removed from the database,
+// and the index of the joined route is returned. The named stops of
+// the joined route are those of both, in order along it.
+func (db *Db) JoinRoutes(i, j int) (int, error) {
+	a, err := db.routeAt(i)
+	if err != nil {
+		return -1, err
+	}
+	b, err := db.routeAt(j)
+	if err != nil {
+		return -1, err
+	}
+	if i == j {
+		return -1, errors.New("cannot join a route to itself")
+	}
+	aEnd, bEnd, d := closestEnds(a.pts, b.pts)
+	if d > joinMeters {
+		return -1, fmt.Errorf("route ends are %.0f m apart", d)
+	}
+
+	bpts, bele, bstops := b.pts, b.ele, b.stops
+	if aEnd == bEnd {
+		bpts, bele = reversed(bpts, bele)
+		bstops = make([]routeStop, len(b.stops))
+		for k, s := range b.stops {
+			bstops[len(b.stops)-1-k] = s
+		}
+	}
+	if aEnd {
+		a.pts, a.ele = joinPaths(a.pts, a.ele, bpts, bele)
+		a.stops = append(append([]routeStop(nil), a.stops...), bstops...)
+	} else {
+		a.pts, a.ele = joinPaths(bpts, bele, a.pts, a.ele)
+		a.stops = append(append([]routeStop(nil), bstops...), a.stops...)
+	}
+	a.update()
+	db.removeRoute(j)
+	db.RecomputeBounds()
+
+	if j < i {
+		i--
+	}
+	return i, nil
+}
+
+// reversed returns reversed copies of pts and the parallel ele.
+func reversed(pts []Stop, ele []float64) ([]Stop, []float64) {
+	rpts := make([]Stop, len(pts))
+	for k, pt := range pts {
+		rpts[len(pts)-1-k] = pt
+	}
+	var rele []float64
+	if ele != nil {
+		rele = make([]float64, len(ele))
+		for k, e := range ele {
+			rele[len(ele)-1-k] = e
+		}
+	}
+	return rpts, rele
+}
+
+// joinPaths returns the path a followed by b, with their parallel
+// elevations, which are kept only if both have them. Where the end of
+// a is the start of b, the point is not repeated.
+func joinPaths(a []Stop, aele []float64, b []Stop, bele []float64) ([]Stop, []float64) {
+	skip := 0
+	if len(a) > 0 && len(b) > 0 && a[len(a)-1] == b[0] {
+		skip = 1
+	}
+	pts := append(append([]Stop{}, a...), b[skip:]...)
+	var ele []float64
+	if aele != nil && bele != nil {
+		ele = append(append([]float64{}, aele...), bele[skip:]...)
+	}
+	return pts, ele
+}
@@ -0,0 +1,117 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEndpointDistanceMatrix(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.50, 72.82}},
+		testRoute{"kg-osh-2", []float64{40.50, 72.82, 40.52, 72.82, 40.52, 72.84}},
+		testRoute{"kg-osh-3", []float64{40.6, 72.9, 40.61, 72.91}},
+	)
+
+	m := db.EndpointDistanceMatrix()
+	if len(m) != 6 {
+		t.Fatalf("matrix has %v rows, expected 6", len(m))
+	}
+	for a := range m {
+		if len(m[a]) != 6 {
+			t.Fatalf("row %v has %v columns, expected 6", a, len(m[a]))
+		}
+		if m[a][a] != 0 {
+			t.Errorf("diagonal %v is %v", a, m[a][a])
+		}
+		for b := range m[a] {
+			if m[a][b] != m[b][a] {
+				t.Errorf("%v,%v is %v but %v,%v is %v", a, b, m[a][b], b, a, m[b][a])
+			}
+		}
+	}
+
+	// The end of route 0 is the start of route 1.
+	if m[1][2] != 0 {
+		t.Errorf("end of 0 to start of 1 is %v", m[1][2])
+	}
+	if exp := distance(Stop{40.5, 72.8}, Stop{40.6, 72.9}); math.Abs(m[0][4]-exp) > 1e-6 {
+		t.Errorf("start of 0 to start of 2 is %v, expected %v", m[0][4], exp)
+	}
+
+	if m := (&Db{}).EndpointDistanceMatrix(); len(m) != 0 {
+		t.Errorf("empty db gave %v", m)
+	}
+}
+
+func TestAreContinuous(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-7a", []float64{40.50, 72.80, 40.50, 72.82}},
+		testRoute{"kg-osh-7b", []float64{40.52, 72.82, 40.50001, 72.82}},
+		testRoute{"kg-osh-8", []float64{40.6, 72.9, 40.61, 72.91}},
+	)
+
+	for _, tc := range []struct {
+		i, j int
+		exp  bool
+	}{
+		{0, 1, true},
+		{1, 0, true},
+		{0, 2, false},
+		{1, 2, false},
+	} {
+		ok, err := db.AreContinuous(tc.i, tc.j, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != tc.exp {
+			t.Errorf("AreContinuous(%v, %v) is %v", tc.i, tc.j, ok)
+		}
+	}
+
+	if ok, _ := db.AreContinuous(0, 1, 1); ok {
+		t.Error("ends 1.1 m apart are continuous within 1 m")
+	}
+	if _, err := db.AreContinuous(0, 3, 5); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestJoinRoutes(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-8", []float64{40.6, 72.9, 40.61, 72.91}},
+		testRoute{"kg-osh-7a", []float64{40.50, 72.80, 40.50, 72.81, 40.50, 72.82}},
+		testRoute{"kg-osh-7b", []float64{40.52, 72.82, 40.51, 72.82, 40.50001, 72.82}},
+	)
+	la, _ := db.RouteLength(1)
+	lb, _ := db.RouteLength(2)
+
+	k, err := db.JoinRoutes(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k != 1 || db.Routes() != 2 {
+		t.Fatalf("joined route is %v of %v", k, db.Routes())
+	}
+	if n, _ := db.RoutePointCount(k); n != 6 {
+		t.Errorf("joined route has %v points, expected 6", n)
+	}
+	pts := db.routes[k].pts
+	if pts[0] != (Stop{40.5, 72.8}) || pts[5] != (Stop{40.52, 72.82}) {
+		t.Errorf("joined route runs from %v to %v", pts[0], pts[5])
+	}
+	l, _ := db.RouteLength(k)
+	gap := distance(Stop{40.5, 72.82}, Stop{40.50001, 72.82})
+	if math.Abs(l-(la+lb+gap)) > 1e-6 {
+		t.Errorf("joined length is %v, expected %v", l, la+lb+gap)
+	}
+	if db.routes[k].md != "kg-osh-7a" {
+		t.Errorf("joined route is %v", db.routes[k].md)
+	}
+
+	if _, err := db.JoinRoutes(0, 1); err == nil {
+		t.Error("expected error joining distant routes")
+	}
+	if _, err := db.JoinRoutes(0, 0); err == nil {
+		t.Error("expected error joining a route to itself")
+	}
+}
@@ -68,7 +68,47 @@ func (rcv *Route) PathLength() int {
 	return 0
 }
 
-func RouteStart(builder *flatbuffers.Builder) { builder.StartObject(4) }
+func (rcv *Route) Circular() bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(12))
+	if o != 0 {
+		return rcv._tab.GetBool(o + rcv._tab.Pos)
+	}
+	return false
+}
+
+func (rcv *Route) Vehicle() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *Route) Color() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *Route) TextColor() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *Route) Wheelchair() int8 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(20))
+	if o != 0 {
+		return rcv._tab.GetInt8(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func RouteStart(builder *flatbuffers.Builder) { builder.StartObject(9) }
 func RouteAddCountry(builder *flatbuffers.Builder, country flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(country), 0)
 }
@@ -81,6 +121,21 @@ func RouteAddName(builder *flatbuffers.Builder, name flatbuffers.UOffsetT) {
 func RouteAddPath(builder *flatbuffers.Builder, path flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(3, flatbuffers.UOffsetT(path), 0)
 }
+func RouteAddCircular(builder *flatbuffers.Builder, circular bool) {
+	builder.PrependBoolSlot(4, circular, false)
+}
+func RouteAddVehicle(builder *flatbuffers.Builder, vehicle flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(5, flatbuffers.UOffsetT(vehicle), 0)
+}
+func RouteAddColor(builder *flatbuffers.Builder, color flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(6, flatbuffers.UOffsetT(color), 0)
+}
+func RouteAddTextColor(builder *flatbuffers.Builder, textColor flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(7, flatbuffers.UOffsetT(textColor), 0)
+}
+func RouteAddWheelchair(builder *flatbuffers.Builder, wheelchair int8) {
+	builder.PrependInt8Slot(8, wheelchair, 0)
+}
 func RouteStartPathVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
 	return builder.StartVector(8, numElems, 4)
 }
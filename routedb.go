@@ -8,13 +8,16 @@ package routedb
 import (
 	"archive/zip"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
 	"strings"
 
 	"github.com/google/flatbuffers/go"
 	"github.com/jeffallen/routedb/route"
-	"github.com/kellydunn/golang-geo"
 	"github.com/rndz/gpx"
 )
 
@@ -31,94 +34,399 @@ type Box struct {
 
 // A Db represents an in-memory copy of the transport database.
 type Db struct {
-	zip    *zip.Reader
-	routes []*gpx.Gpx
-	bounds Box
+	zip      *zip.Reader
+	routes   []*track
+	bounds   Box
+	warnings []string
+	index    *kdTree // nil if noIndex
+	noIndex  bool
+
+	// stopIndex indexes the boarding points of each route, as
+	// given by boardingPoints. It is nil if noIndex, or if no route
+	// has named stops, when it would be the same as index.
+	stopIndex *kdTree
+
+	stopsByID map[string]*stopEntry // see indexStops
+
+	savedTransfers []byte // TransferTable read from transfersFile, or nil
+
+	locale string // see SetLocale
+}
+
+// LoadOptions control how a routedb is loaded.
+type LoadOptions struct {
+	// KeepEmptyRoutes keeps routes that have no waypoints, which
+	// are then left out of the bounds. By default such routes are
+	// skipped, and a warning is reported by Warnings.
+	KeepEmptyRoutes bool
+
+	// NoSpatialIndex skips building the spatial index that speeds
+	// up Nearest and the other nearest waypoint queries, saving
+	// memory on constrained devices at the cost of scanning every
+	// waypoint on each query.
+	NoSpatialIndex bool
+
+	// StrictCountries makes it an error for a route to have a
+	// country which is not an ISO 3166-1 code or English name. By
+	// default the country is kept as it is, and a warning is
+	// reported by Warnings. Known countries are always normalized,
+	// as described for CountryCode.
+	StrictCountries bool
+}
+
+// A track is the in-memory form of one route: its metadata and the
+// waypoints of its single track segment.
+type track struct {
+	id         string            // stable identifier, see RouteID
+	idGiven    bool              // id is from the route's metadata, not assignIDs
+	md         string            // metadata name, country-city-name
+	key        *routeKey         // from the route's metadata, or nil; see split
+	pts        []Stop            // the path
+	ele        []float64         // elevations parallel to pts, or nil if none
+	tags       []string          // from the GPX metadata keywords
+	vehicle    string            // from the GPX track type, see RouteVehicle
+	color      string            // RRGGBB or empty, see RouteColors
+	textColor  string            // likewise
+	agencyID   string            // from the GPX track extensions
+	agency     *Agency           // the agency with agencyID, or nil
+	fare       *Fare             // from the GPX track extensions, or nil
+	wheelchair int               // see RouteWheelchair
+	names      map[string]string // localized names by locale, see RouteName
+	stops      []routeStop       // named stops, from the GPX <wpt> elements
+	src        []byte            // the GPX file, or nil if not loaded from one
+
+	// Cached values derived from pts, kept current by update.
+	length   float64 // meters
+	bounds   Box
+	circular bool // see IsCircular
+}
+
+// update recomputes the values cached on t. It must be called
+// whenever t.pts changes.
+func (t *track) update() {
+	t.length = pathLength(t.pts)
+	t.bounds = boundsOf([]*track{t})
+	t.circular = isCircular(t.pts, t.length)
+}
+
+// newTrack converts a parsed GPX file into a track. The GPX parser
+// does not distinguish a missing <ele> from zero, so a track whose
+// points are all at zero elevation is taken to have no elevation data.
+func newTrack(g *gpx.Gpx) *track {
+	trkpt := g.Trk[0].Trkseg[0].Trkpt
+	t := &track{
+		md:      g.Metadata.Name,
+		pts:     make([]Stop, len(trkpt)),
+		tags:    parseTags(g.Metadata.Keywords),
+		vehicle: normalizeVehicle(g.Trk[0].Type),
+		stops:   stopsOf(g),
+	}
+	hasEle := false
+	for j, pt := range trkpt {
+		t.pts[j] = Stop{Lat: pt.Lat, Lon: pt.Lon}
+		if pt.Ele != 0 {
+			hasEle = true
+		}
+	}
+	if hasEle {
+		t.ele = make([]float64, len(trkpt))
+		for j, pt := range trkpt {
+			t.ele[j] = pt.Ele
+		}
+	}
+	t.update()
+	return t
 }
 
 // Load loads a routedb, returning a Db that can be queried, or an
 // error.
 func Load(in []byte) (db *Db, err error) {
-	db = &Db{}
+	return LoadWithOptions(in, nil)
+}
+
+// LoadWithOptions is like Load, but lets the caller control how the
+// routedb is loaded. A nil opts gives the defaults.
+func LoadWithOptions(in []byte, opts *LoadOptions) (db *Db, err error) {
+	return loadZip(context.Background(), in, opts)
+}
+
+// loadZip loads a routedb from the zip in, giving up if ctx is done.
+func loadZip(ctx context.Context, in []byte, opts *LoadOptions) (db *Db, err error) {
+	db = &Db{noIndex: opts != nil && opts.NoSpatialIndex}
 	db.zip, err = zip.NewReader(bytes.NewReader(in), int64(len(in)))
+	if err != nil {
+		return nil, err
+	}
+	agencies := make(map[string]*Agency)
+	var metadata []*routeMetadata
+	files := make(map[string]*track)
 	for _, zf := range db.zip.File {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		file, err := zf.Open()
 		fn := zf.FileHeader.Name
 		if err != nil {
 			return nil, fmt.Errorf("Failed to read file %v: %v", fn, err)
 		}
-		gpx, err := gpx.Parse(file)
-		if err != nil {
-			return nil, fmt.Errorf("Failed to parse %v: %v", fn, err)
-		}
-		if len(gpx.Trk) != 1 {
-			return nil, fmt.Errorf("In file %v expected 1 track, found %v", fn, len(gpx.Trk))
-		}
-		if len(gpx.Trk[0].Trkseg) != 1 {
-			return nil, fmt.Errorf("In file %v expected 1 track segment, found %v", fn, len(gpx.Trk[0].Trkseg))
-		}
-		db.routes = append(db.routes, gpx)
-	}
-
-	// If we have any points at all, use the first one as the anchor for
-	// the bounds, then expand the bounds by processing the rest.
-	if len(db.routes) >= 1 && len(db.routes[0].Trk[0].Trkseg[0].Trkpt) >= 1 {
-		pt0 := db.routes[0].Trk[0].Trkseg[0].Trkpt[0]
-		db.bounds.N, db.bounds.E = pt0.Lat, pt0.Lon
-		db.bounds.S, db.bounds.W = pt0.Lat, pt0.Lon
-		for _, route := range db.routes {
-			for _, pt := range route.Trk[0].Trkseg[0].Trkpt {
-				if pt.Lat > db.bounds.N {
-					db.bounds.N = pt.Lat
-				}
-				if pt.Lon > db.bounds.E {
-					db.bounds.E = pt.Lon
-				}
-				if pt.Lat < db.bounds.S {
-					db.bounds.S = pt.Lat
-				}
-				if pt.Lon < db.bounds.W {
-					db.bounds.W = pt.Lon
+		switch fn {
+		case transfersFile:
+			db.savedTransfers, err = ioutil.ReadAll(file)
+			if err != nil {
+				err = fmt.Errorf("Failed to read file %v: %v", fn, err)
+			} else if !checkTransferTable(db.savedTransfers) {
+				// It is only a cache, so the table can be
+				// computed again instead.
+				db.warnings = append(db.warnings, fmt.Sprintf("Ignored %v: not a valid transfer table", fn))
+				db.savedTransfers = nil
+			}
+		case agenciesFile:
+			err = parseAgencies(fn, file, agencies)
+		case metadataFile:
+			var m []*routeMetadata
+			if m, err = parseMetadata(fn, file, agencies); err == nil {
+				metadata = append(metadata, m...)
+			}
+		default:
+			if path.Ext(fn) == ".json" {
+				var m *routeMetadata
+				if m, err = parseRouteMetadata(fn, file); err == nil {
+					metadata = append(metadata, m)
 				}
+				break
+			}
+			var t *track
+			if t, err = parseTrack(fn, file); err == nil {
+				files[fn] = t
+				db.addTrack(fn, t, opts)
 			}
 		}
-	} else {
-		// No waypoints in our db, so leave the bounds at the zero
-		// value.
+		file.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := db.finishLoad(metadata, files, agencies, opts); err != nil {
+		return nil, err
 	}
+	return db, nil
+}
 
-	return db, err
+// finishLoad completes loading db once its files are read, whatever
+// their format: it applies metadata to the tracks read from the GPX
+// files by name in files, normalizes the countries, gives the routes
+// their IDs and agencies, and recomputes the bounds.
+func (db *Db) finishLoad(metadata []*routeMetadata, files map[string]*track, agencies map[string]*Agency, opts *LoadOptions) error {
+	if err := db.applyMetadata(metadata, files); err != nil {
+		return err
+	}
+	if err := db.normalizeCountries(opts != nil && opts.StrictCountries); err != nil {
+		return err
+	}
+	db.assignIDs()
+	db.resolveAgencies(agencies)
+	db.RecomputeBounds()
+	return nil
 }
 
-// This can't be global because gobind cannot handle it.
-// TODO: File an issue on this bug.
-//var ErrNoStop = errors.New("No stop found matching criteria.")
+// addTrack adds t, read from the file fn, to the routes of db, unless
+// opts say it should be skipped.
+func (db *Db) addTrack(fn string, t *track, opts *LoadOptions) {
+	if len(t.pts) == 0 && (opts == nil || !opts.KeepEmptyRoutes) {
+		db.warnings = append(db.warnings, fmt.Sprintf("Skipped %v: no waypoints", fn))
+		return
+	}
+	db.routes = append(db.routes, t)
+}
 
-func (db *Db) Nearest(lat, lon float64) (stop *Stop, err error) {
-	p1 := geo.NewPoint(lat, lon)
-	err = errors.New("No stop found matching criteria.")
-	minD := 1e10
-
-	for _, route := range db.routes {
-		for _, trkpt := range route.Trk[0].Trkseg[0].Trkpt {
-			p2 := geo.NewPoint(trkpt.Lat, trkpt.Lon)
-			d := p1.GreatCircleDistance(p2)
-			if d < minD {
-				minD = d
-				stop = &Stop{Lat: p2.Lat(), Lon: p2.Lng()}
-				err = nil
+// Warnings returns the warnings raised while loading the routedb, one
+// per line, or the empty string if there were none.
+func (db *Db) Warnings() string {
+	return strings.Join(db.warnings, "\n")
+}
+
+// parseTrack parses the GPX file fn, read from r, into a track.
+func parseTrack(fn string, r io.Reader) (*track, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read file %v: %v", fn, err)
+	}
+	gpx, err := parseGPX(fn, src)
+	if err != nil {
+		return nil, err
+	}
+	t := newTrack(gpx)
+	t.src = src
+	t.applyExtensions(src)
+	return t, nil
+}
+
+// parseGPX parses the GPX file fn, checking that it holds a single
+// track segment.
+func parseGPX(fn string, src []byte) (*gpx.Gpx, error) {
+	gpx, err := gpx.Parse(bytes.NewReader(src))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", fn, err)
+	}
+	if len(gpx.Trk) != 1 {
+		return nil, fmt.Errorf("In file %v expected 1 track, found %v", fn, len(gpx.Trk))
+	}
+	if len(gpx.Trk[0].Trkseg) != 1 {
+		return nil, fmt.Errorf("In file %v expected 1 track segment, found %v", fn, len(gpx.Trk[0].Trkseg))
+	}
+	return gpx, nil
+}
+
+// RouteGPX returns the GPX file route i was loaded from, parsed in
+// full, for tools needing more than the routedb keeps. Changes made
+// to the route since it was loaded are not reflected. Routes made
+// with a Builder have no GPX file.
+func (db *Db) RouteGPX(i int) (*gpx.Gpx, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	if t.src == nil {
+		return nil, errors.New("route was not loaded from GPX")
+	}
+	return parseGPX(t.id, t.src)
+}
+
+// RecomputeBounds recomputes the box bounding all the waypoints in all
+// the routes, as returned by Bounds, and rebuilds the spatial indexes
+// used by Nearest and the index of named stops used by StopByID and
+// RoutesAtStopID. They are all computed at load time; call this to
+// bring them up to date after changing routes.
+func (db *Db) RecomputeBounds() {
+	db.bounds = boundsOf(db.routes)
+	db.indexStops()
+	db.index, db.stopIndex = nil, nil
+	if !db.noIndex {
+		db.index = newKdTree(db.routes, waypoints)
+		if db.hasNamedStops() {
+			db.stopIndex = newKdTree(db.routes, boardingPoints)
+		}
+	}
+}
+
+// boundsOf returns the box bounding all the waypoints in routes.
+func boundsOf(routes []*track) (b Box) {
+	// Use the first point as the anchor for the bounds, then expand
+	// the bounds by processing the rest. If there are no points at
+	// all, the bounds stay at the zero value.
+	anchored := false
+	for _, route := range routes {
+		for _, pt := range route.pts {
+			if !anchored {
+				b = Box{N: pt.Lat, E: pt.Lon, S: pt.Lat, W: pt.Lon}
+				anchored = true
 			}
+			b.expand(pt)
 		}
 	}
 	return
 }
 
+// expand grows b as needed to include pt.
+func (b *Box) expand(pt Stop) {
+	if pt.Lat > b.N {
+		b.N = pt.Lat
+	}
+	if pt.Lon > b.E {
+		b.E = pt.Lon
+	}
+	if pt.Lat < b.S {
+		b.S = pt.Lat
+	}
+	if pt.Lon < b.W {
+		b.W = pt.Lon
+	}
+}
+
+// errNoStop is the error returned when a query finds no stop. Being
+// unexported, it does not trouble gobind.
+var errNoStop = errors.New("No stop found matching criteria.")
+
+// These errors are likewise unexported. Go callers can recognize them
+// with IsOutOfRange and IsEmptyRoute.
+var (
+	errOutOfRange = errors.New("out of range")
+	errEmptyRoute = errors.New("route has no waypoints")
+)
+
+// IsOutOfRange reports whether err is the error returned when asked
+// for a route that does not exist.
+func IsOutOfRange(err error) bool {
+	return err == errOutOfRange
+}
+
+// IsEmptyRoute reports whether err is the error returned when asked
+// for something a route with no waypoints does not have.
+func IsEmptyRoute(err error) bool {
+	return err == errEmptyRoute
+}
+
+// Nearest returns the stop nearest to lat, lon. The named stops of
+// routes which have them are preferred to their waypoints, which only
+// give the shape of the path; on routes without named stops, any
+// waypoint is taken to be a stop.
+func (db *Db) Nearest(lat, lon float64) (stop *Stop, err error) {
+	if err := checkLatLon(lat, lon); err != nil {
+		return nil, err
+	}
+	ri, k, _ := db.nearestBoarding(Stop{lat, lon})
+	if ri < 0 {
+		return nil, errNoStop
+	}
+	pt := db.routes[ri].boardingPoint(k)
+	return &Stop{Lat: pt.Lat, Lon: pt.Lon}, nil
+}
+
 // Bounds returns the box bounding all the waypoints in all the routes
 // in the database. It returns a *Box to be compatible with gobind.
 func (db *Db) Bounds() *Box {
 	return &db.bounds
 }
 
+// RouteBounds returns the box bounding route i, for zooming a map to
+// it. The box is computed when the route is loaded. A route with no
+// waypoints has the zero value Box.
+func (db *Db) RouteBounds(i int) (*Box, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	b := t.bounds
+	return &b, nil
+}
+
+// AllRouteBounds returns the box bounding each route, indexed like
+// the routes. A route with no waypoints has the zero value Box.
+func (db *Db) AllRouteBounds() []*Box {
+	boxes := make([]*Box, len(db.routes))
+	for i, t := range db.routes {
+		b := t.bounds
+		boxes[i] = &b
+	}
+	return boxes
+}
+
+// BoundsOf returns the box bounding all the waypoints in the routes
+// with the given indices. An empty list of indices gives the zero
+// value Box.
+func (db *Db) BoundsOf(indices []int) (*Box, error) {
+	routes := make([]*track, len(indices))
+	for k, i := range indices {
+		t, err := db.routeAt(i)
+		if err != nil {
+			return nil, err
+		}
+		routes[k] = t
+	}
+	b := boundsOf(routes)
+	return &b, nil
+}
+
 func split_md(in string) (country, city, name string) {
 	x := strings.SplitN(in, "-", 3)
 	if len(x) == 3 {
@@ -127,40 +435,208 @@ func split_md(in string) (country, city, name string) {
 	return
 }
 
+// removeRoute removes route i from the database. The caller is
+// responsible for recomputing the bounds.
+func (db *Db) removeRoute(i int) {
+	db.routes = append(db.routes[:i:i], db.routes[i+1:]...)
+}
+
 // Routes returns the number of routes.
 func (db *Db) Routes() int {
 	return len(db.routes)
 }
 
+// routeAt returns route i, or an error if i is out of range.
+func (db *Db) routeAt(i int) (*track, error) {
+	if i < 0 || i >= len(db.routes) {
+		return nil, errOutOfRange
+	}
+	return db.routes[i], nil
+}
+
+// micro quantizes a coordinate in degrees to the microdegrees used in
+// the FlatBuffer encoding.
+func micro(v float64) int32 {
+	return int32(v * 1e6)
+}
+
+// TotalWaypoints returns the number of waypoints in all the routes.
+func (db *Db) TotalWaypoints() int {
+	n := 0
+	for _, t := range db.routes {
+		n += len(t.pts)
+	}
+	return n
+}
+
+// RoutePointCount returns the number of waypoints in route i.
+func (db *Db) RoutePointCount(i int) (int, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return 0, err
+	}
+	return len(t.pts), nil
+}
+
+// EachWaypoint calls fn for every waypoint of every route, in order
+// of route and then of position along the route, stopping early if
+// fn returns false.
+func (db *Db) EachWaypoint(fn func(routeIndex, pointIndex int, lat, lon float64) bool) {
+	for i, t := range db.routes {
+		for j, pt := range t.pts {
+			if !fn(i, j, pt.Lat, pt.Lon) {
+				return
+			}
+		}
+	}
+}
+
 // Route returns the selected route as a FlatBuffer.
 func (db *Db) Route(i int) ([]byte, error) {
-	if i >= len(db.routes) {
-		return nil, errors.New("out of range")
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
 	}
+	return db.finishRoute(t, t.pts), nil
+}
 
-	gpx := db.routes[i]
-	country, city, name := split_md(gpx.Metadata.Name)
-
+// finishRoute returns a FlatBuffer holding a Route with the metadata
+// of t and path pts, which may be t's own or derived from it.
+func (db *Db) finishRoute(t *track, pts []Stop) []byte {
 	b := flatbuffers.NewBuilder(0)
+	b.Finish(db.buildRoute(b, t, pts))
+	return b.Bytes[b.Head():]
+}
+
+// buildRoute builds a Route with the metadata of t and path pts in b,
+// returning its offset. The name is in the locale set by SetLocale,
+// and the route is flagged as circular if pts is, as IsCircular tells.
+func (db *Db) buildRoute(b *flatbuffers.Builder, t *track, pts []Stop) flatbuffers.UOffsetT {
+	country, city, _ := t.split()
+	name := t.name(db.locale)
 
 	l1 := b.CreateString(country)
 	l2 := b.CreateString(city)
 	l3 := b.CreateString(name)
-	route.RouteStartPathVector(b, len(gpx.Trk[0].Trkseg[0].Trkpt))
-	for j := len(gpx.Trk[0].Trkseg[0].Trkpt) - 1; j >= 0; j-- {
-		trkpt := gpx.Trk[0].Trkseg[0].Trkpt[j]
-		lat := int32(trkpt.Lat * 1e6)
-		lon := int32(trkpt.Lon * 1e6)
-		route.CreateGeoPoint(b, lat, lon)
+	var l5, l6, l7 flatbuffers.UOffsetT
+	if t.vehicle != "" {
+		l5 = b.CreateString(t.vehicle)
 	}
-	l4 := b.EndVector(len(gpx.Trk[0].Trkseg[0].Trkpt))
+	if t.color != "" {
+		l6 = b.CreateString(t.color)
+	}
+	if t.textColor != "" {
+		l7 = b.CreateString(t.textColor)
+	}
+	route.RouteStartPathVector(b, len(pts))
+	for j := len(pts) - 1; j >= 0; j-- {
+		route.CreateGeoPoint(b, micro(pts[j].Lat), micro(pts[j].Lon))
+	}
+	l4 := b.EndVector(len(pts))
 
 	route.RouteStart(b)
 	route.RouteAddCountry(b, l1)
 	route.RouteAddCity(b, l2)
 	route.RouteAddName(b, l3)
 	route.RouteAddPath(b, l4)
-	b.Finish(route.RouteEnd(b))
+	route.RouteAddCircular(b, isCircular(pts, pathLength(pts)))
+	if t.vehicle != "" {
+		route.RouteAddVehicle(b, l5)
+	}
+	if t.color != "" {
+		route.RouteAddColor(b, l6)
+	}
+	if t.textColor != "" {
+		route.RouteAddTextColor(b, l7)
+	}
+	route.RouteAddWheelchair(b, int8(t.wheelchair))
+	return route.RouteEnd(b)
+}
+
+// RouteMeta returns the country, city, name, length in meters and
+// bounds of route i as a RouteMeta FlatBuffer. Unlike Route, it leaves
+// out the path, so it is cheap enough to fetch for every row of a list
+// of routes. The name is in the locale set by SetLocale.
+func (db *Db) RouteMeta(i int) ([]byte, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	country, city, _ := t.split()
+	b := flatbuffers.NewBuilder(0)
+	l1 := b.CreateString(country)
+	l2 := b.CreateString(city)
+	l3 := b.CreateString(t.name(db.locale))
+	route.RouteMetaStart(b)
+	route.RouteMetaAddCountry(b, l1)
+	route.RouteMetaAddCity(b, l2)
+	route.RouteMetaAddName(b, l3)
+	route.RouteMetaAddLength(b, t.length)
+	route.RouteMetaAddSouthWest(b, route.CreateGeoPoint(b, micro(t.bounds.S), micro(t.bounds.W)))
+	route.RouteMetaAddNorthEast(b, route.CreateGeoPoint(b, micro(t.bounds.N), micro(t.bounds.E)))
+	b.Finish(route.RouteMetaEnd(b))
+	return b.Bytes[b.Head():], nil
+}
+
+// RouteHighPrecision is like Route, but returns a RouteHP, whose path
+// keeps the full float64 precision of the coordinates rather than
+// quantizing them to microdegrees. It is twice the size, so use it
+// only where the precision matters, such as for survey data.
+func (db *Db) RouteHighPrecision(i int) ([]byte, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	country, city, _ := t.split()
+
+	b := flatbuffers.NewBuilder(0)
+
+	l1 := b.CreateString(country)
+	l2 := b.CreateString(city)
+	l3 := b.CreateString(t.name(db.locale))
+	route.RouteHPStartPathVector(b, len(t.pts))
+	for j := len(t.pts) - 1; j >= 0; j-- {
+		route.CreateGeoPointHP(b, t.pts[j].Lat, t.pts[j].Lon)
+	}
+	l4 := b.EndVector(len(t.pts))
+
+	route.RouteHPStart(b)
+	route.RouteHPAddCountry(b, l1)
+	route.RouteHPAddCity(b, l2)
+	route.RouteHPAddName(b, l3)
+	route.RouteHPAddPath(b, l4)
+	b.Finish(route.RouteHPEnd(b))
 
 	return b.Bytes[b.Head():], nil
 }
+
+// RoutePathMicro returns the coordinates of the selected route in
+// microdegrees, quantized exactly as in the FlatBuffer returned by
+// Route, but without building the FlatBuffer.
+func (db *Db) RoutePathMicro(i int) (lats, lons []int32, err error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, nil, err
+	}
+	lats = make([]int32, len(t.pts))
+	lons = make([]int32, len(t.pts))
+	for j, pt := range t.pts {
+		lats[j], lons[j] = micro(pt.Lat), micro(pt.Lon)
+	}
+	return lats, lons, nil
+}
+
+// RouteCoordsFlat returns the coordinates of the selected route in
+// degrees, flattened into one slice as lat0, lon0, lat1, lon1, and so
+// on. This crosses gobind much more cheaply than a slice of Stops.
+func (db *Db) RouteCoordsFlat(i int) ([]float64, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	coords := make([]float64, 0, 2*len(t.pts))
+	for _, pt := range t.pts {
+		coords = append(coords, pt.Lat, pt.Lon)
+	}
+	return coords, nil
+}
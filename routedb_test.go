@@ -1,7 +1,11 @@
 package routedb
 
 import (
+	"archive/zip"
+	"bytes"
+	"fmt"
 	"io/ioutil"
+	"math"
 	"testing"
 
 	"github.com/jeffallen/routedb/route"
@@ -21,6 +25,83 @@ func init() {
 	}
 }
 
+// mustRead returns the contents of file fn.
+func mustRead(t *testing.T, fn string) []byte {
+	bytes, err := ioutil.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes
+}
+
+// loadTestdata loads the routedb in file fn.
+func loadTestdata(t *testing.T, fn string) *Db {
+	db, err := Load(mustRead(t, fn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// A testRoute describes a synthetic route: its metadata name and its
+// waypoints as alternating lat, lon values.
+type testRoute struct {
+	md     string
+	latlon []float64
+}
+
+// makeDb builds a Db by loading a zip holding one GPX file per route.
+func makeDb(t *testing.T, routes ...testRoute) *Db {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for n, r := range routes {
+		w, err := zw.Create(fmt.Sprintf("%v.xml", n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(w, "<gpx><metadata><name>%v</name></metadata><trk><trkseg>\n", r.md)
+		for j := 0; j+1 < len(r.latlon); j += 2 {
+			fmt.Fprintf(w, "<trkpt lat=\"%v\" lon=\"%v\"/>\n", r.latlon[j], r.latlon[j+1])
+		}
+		fmt.Fprintf(w, "</trkseg></trk></gpx>\n")
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	db, err := Load(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// zipGPX returns a zip holding the given GPX files.
+func zipGPX(t *testing.T, files ...string) []byte {
+	var named []string
+	for n, f := range files {
+		named = append(named, fmt.Sprintf("%v.xml", n), f)
+	}
+	return zipFiles(t, named...)
+}
+
+// zipFiles returns a zip holding files given as alternating names and
+// contents.
+func zipFiles(t *testing.T, files ...string) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for k := 0; k+1 < len(files); k += 2 {
+		w, err := zw.Create(files[k])
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(files[k+1]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
 func TestRoutes(t *testing.T) {
 	buf, err := db.Route(0)
 	if err != nil {
@@ -37,6 +118,156 @@ func TestRoutes(t *testing.T) {
 	}
 }
 
+func TestRouteHighPrecision(t *testing.T) {
+	lat, lon := 40.123456789012, 72.987654321098
+	b := NewBuilder()
+	b.AddRoute("kg", "osh", "survey", []float64{40.5, lat}, []float64{72.8, lon})
+	db, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := db.RouteHighPrecision(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := route.GetRootAsRouteHP(buf, 0)
+	if string(r.Country()) != "kg" || string(r.Name()) != "survey" || r.PathLength() != 2 {
+		t.Fatalf("unexpected route %v/%v with %v points", string(r.Country()), string(r.Name()), r.PathLength())
+	}
+	pt := &route.GeoPointHP{}
+	r.Path(pt, 1)
+	if pt.Lat() != lat || pt.Lon() != lon {
+		t.Errorf("point is %v/%v, expected %v/%v", pt.Lat(), pt.Lon(), lat, lon)
+	}
+
+	if _, err := db.RouteHighPrecision(1); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRouteMeta(t *testing.T) {
+	b := NewBuilder()
+	b.AddRoute("kg", "osh", "1", []float64{40.5, 40.51, 40.49}, []float64{72.8, 72.81, 72.82})
+	sdb, err := b.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := sdb.RouteMeta(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := route.GetRootAsRouteMeta(buf, 0)
+	if string(m.Country()) != "kg" || string(m.City()) != "osh" || string(m.Name()) != "1" {
+		t.Errorf("route is %s-%s-%s", m.Country(), m.City(), m.Name())
+	}
+	if l, _ := sdb.RouteLength(0); m.Length() != l {
+		t.Errorf("length is %v, expected %v", m.Length(), l)
+	}
+	sw, ne := m.SouthWest(nil), m.NorthEast(nil)
+	if sw.Lat() != micro(40.49) || sw.Lon() != micro(72.8) || ne.Lat() != micro(40.51) || ne.Lon() != micro(72.82) {
+		t.Errorf("bounds are %v,%v to %v,%v", sw.Lat(), sw.Lon(), ne.Lat(), ne.Lon())
+	}
+
+	// It is much smaller than the route with its path.
+	full, _ := db.Route(0)
+	meta, err := db.RouteMeta(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta)*10 > len(full) {
+		t.Errorf("meta is %v bytes, route is %v", len(meta), len(full))
+	}
+
+	if _, err := sdb.RouteMeta(1); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
+
+func TestRoutePathMicro(t *testing.T) {
+	lats, lons, err := db.RoutePathMicro(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := db.Route(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := route.GetRootAsRoute(buf, 0)
+	if len(lats) != r.PathLength() || len(lons) != r.PathLength() {
+		t.Fatalf("got %v/%v points, expected %v", len(lats), len(lons), r.PathLength())
+	}
+	pt := &route.GeoPoint{}
+	for j := 0; j < r.PathLength(); j++ {
+		r.Path(pt, j)
+		if lats[j] != pt.Lat() || lons[j] != pt.Lon() {
+			t.Errorf("point %v is %v/%v, expected %v/%v", j, lats[j], lons[j], pt.Lat(), pt.Lon())
+		}
+	}
+
+	if _, _, err := db.RoutePathMicro(1); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestEachWaypoint(t *testing.T) {
+	db := loadTestdata(t, "testdata/cities.zip")
+
+	n := 0
+	lastRoute, lastPoint := 0, -1
+	db.EachWaypoint(func(routeIndex, pointIndex int, lat, lon float64) bool {
+		if routeIndex == lastRoute && pointIndex != lastPoint+1 ||
+			routeIndex != lastRoute && (routeIndex != lastRoute+1 || pointIndex != 0) {
+			t.Errorf("visited %v/%v after %v/%v", routeIndex, pointIndex, lastRoute, lastPoint)
+		}
+		lastRoute, lastPoint = routeIndex, pointIndex
+		n++
+		return true
+	})
+	if n != db.TotalWaypoints() {
+		t.Errorf("visited %v waypoints, expected %v", n, db.TotalWaypoints())
+	}
+
+	n = 0
+	db.EachWaypoint(func(routeIndex, pointIndex int, lat, lon float64) bool {
+		n++
+		return n < 3
+	})
+	if n != 3 {
+		t.Errorf("visited %v waypoints after stopping at 3", n)
+	}
+}
+
+func TestRouteCoordsFlat(t *testing.T) {
+	coords, err := db.RouteCoordsFlat(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := db.RoutePointCount(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 477 {
+		t.Errorf("point count is %v", n)
+	}
+	if len(coords) != 2*n {
+		t.Fatalf("got %v coordinates for %v points", len(coords), n)
+	}
+	for j, pt := range db.routes[0].pts {
+		if coords[2*j] != pt.Lat || coords[2*j+1] != pt.Lon {
+			t.Errorf("point %v is %v/%v, expected %v", j, coords[2*j], coords[2*j+1], pt)
+		}
+	}
+
+	if _, err := db.RouteCoordsFlat(1); err == nil {
+		t.Error("expected out of range error")
+	}
+	if _, err := db.RoutePointCount(1); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
 func TestNearest(t *testing.T) {
 	// a known point is: lat 40.50263 lon 72.821976
 	// so we ask for a point near that and expect it to come back
@@ -53,6 +284,28 @@ func TestNearest(t *testing.T) {
 	}
 }
 
+func TestNearestInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		lat, lon float64
+		err      string
+	}{
+		{math.NaN(), 72.8, "Invalid latitude NaN"},
+		{40.5, math.NaN(), "Invalid longitude NaN"},
+		{math.Inf(1), 72.8, "Invalid latitude +Inf"},
+		{91, 72.8, "Invalid latitude 91"},
+		{40.5, -181, "Invalid longitude -181"},
+	} {
+		_, err := db.Nearest(tc.lat, tc.lon)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("Nearest(%v, %v) error is %v, expected %v", tc.lat, tc.lon, err, tc.err)
+		}
+		_, err = db.NearestDense(tc.lat, tc.lon)
+		if err == nil || err.Error() != tc.err {
+			t.Errorf("NearestDense(%v, %v) error is %v, expected %v", tc.lat, tc.lon, err, tc.err)
+		}
+	}
+}
+
 func TestBounds(t *testing.T) {
 	b := db.Bounds()
 	// These expected values were checked by putting the .xml file
@@ -64,3 +317,187 @@ func TestBounds(t *testing.T) {
 		t.Error("bounds e/w wrong")
 	}
 }
+
+func TestBoundsOf(t *testing.T) {
+	db := loadTestdata(t, "testdata/cities.zip")
+
+	all := make([]int, db.Routes())
+	for i := range all {
+		all[i] = i
+	}
+	b, err := db.BoundsOf(all)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *b != *db.Bounds() {
+		t.Errorf("bounds of all routes %v, expected %v", *b, *db.Bounds())
+	}
+
+	b, err = db.BoundsOf([]int{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *b != (Box{N: 42.88, E: 74.60, S: 42.87, W: 74.59}) {
+		t.Errorf("bounds of route 2 are %v", *b)
+	}
+
+	b, err = db.BoundsOf(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *b != (Box{}) {
+		t.Errorf("bounds of no routes are %v", *b)
+	}
+
+	if _, err := db.BoundsOf([]int{0, 4}); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestLoadEmptyRoute(t *testing.T) {
+	bytes, err := ioutil.ReadFile("testdata/empty.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expBounds := Box{N: 40.53, E: 72.83, S: 40.5, W: 72.8}
+
+	// By default the empty route is skipped, with a warning.
+	db, err := Load(bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Routes() != 2 {
+		t.Errorf("got %v routes, expected 2", db.Routes())
+	}
+	if db.Warnings() != "Skipped kg-osh-empty.xml: no waypoints" {
+		t.Errorf("warnings are %q", db.Warnings())
+	}
+	if *db.Bounds() != expBounds {
+		t.Errorf("bounds are %v, expected %v", *db.Bounds(), expBounds)
+	}
+
+	// When kept, it does not affect the bounds.
+	db, err = LoadWithOptions(bytes, &LoadOptions{KeepEmptyRoutes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.Routes() != 3 {
+		t.Errorf("got %v routes, expected 3", db.Routes())
+	}
+	if db.Warnings() != "" {
+		t.Errorf("warnings are %q", db.Warnings())
+	}
+	if *db.Bounds() != expBounds {
+		t.Errorf("bounds are %v, expected %v", *db.Bounds(), expBounds)
+	}
+	if l, err := db.RouteLength(1); err != nil || l != 0 {
+		t.Errorf("empty route length is %v, %v", l, err)
+	}
+}
+
+func TestRouteBounds(t *testing.T) {
+	cdb := loadTestdata(t, "testdata/cities.zip")
+	for i := 0; i < cdb.Routes(); i++ {
+		b, err := cdb.RouteBounds(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exp := boundsOf(cdb.routes[i : i+1]); *b != exp {
+			t.Errorf("route %v bounds %v, expected %v", i, *b, exp)
+		}
+	}
+	b, _ := db.RouteBounds(0)
+	if *b != *db.Bounds() {
+		t.Errorf("single route bounds %v, expected %v", *b, *db.Bounds())
+	}
+	// The box returned is a copy.
+	b.N = 0
+	if b, _ := db.RouteBounds(0); b.N == 0 {
+		t.Error("route bounds changed through returned box")
+	}
+	if _, err := db.RouteBounds(1); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
+
+func TestAllRouteBounds(t *testing.T) {
+	bytes, err := ioutil.ReadFile("testdata/empty.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadWithOptions(bytes, &LoadOptions{KeepEmptyRoutes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	boxes := db.AllRouteBounds()
+	if len(boxes) != db.Routes() {
+		t.Fatalf("got %v boxes for %v routes", len(boxes), db.Routes())
+	}
+	if *boxes[1] != (Box{}) {
+		t.Errorf("empty route has bounds %v", *boxes[1])
+	}
+	union := *boxes[0]
+	union.expand(Stop{boxes[2].N, boxes[2].E})
+	union.expand(Stop{boxes[2].S, boxes[2].W})
+	if union != *db.Bounds() {
+		t.Errorf("union of route bounds is %v, expected %v", union, *db.Bounds())
+	}
+}
+
+func TestErrorPredicates(t *testing.T) {
+	db, err := LoadWithOptions(mustRead(t, "testdata/empty.zip"), &LoadOptions{KeepEmptyRoutes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Route(3)
+	if !IsOutOfRange(err) || IsEmptyRoute(err) {
+		t.Errorf("Route(3) error %v not recognized as out of range", err)
+	}
+	_, err = db.Route(-1)
+	if !IsOutOfRange(err) {
+		t.Errorf("Route(-1) error %v not recognized as out of range", err)
+	}
+
+	_, err = db.RouteGeneralBearing(1)
+	if !IsEmptyRoute(err) || IsOutOfRange(err) {
+		t.Errorf("empty route error %v not recognized", err)
+	}
+
+	_, err = db.Nearest(math.NaN(), 0)
+	if IsOutOfRange(err) || IsEmptyRoute(err) {
+		t.Errorf("invalid position error %v misrecognized", err)
+	}
+	if IsOutOfRange(nil) || IsEmptyRoute(nil) {
+		t.Error("nil error recognized")
+	}
+}
+
+func TestRouteGPX(t *testing.T) {
+	g, err := db.RouteGPX(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Trk) != 1 || len(g.Trk[0].Trkseg) != 1 {
+		t.Fatalf("GPX has %v tracks", len(g.Trk))
+	}
+	trkpt := g.Trk[0].Trkseg[0].Trkpt
+	if len(trkpt) != 477 || trkpt[0].Lat != db.routes[0].pts[0].Lat {
+		t.Errorf("GPX track has %v points starting at %v", len(trkpt), trkpt[0])
+	}
+	if g.Metadata.Name != "kg-osh-149" {
+		t.Errorf("GPX name is %v", g.Metadata.Name)
+	}
+
+	if _, err := db.RouteGPX(1); !IsOutOfRange(err) {
+		t.Errorf("expected out of range error, got %v", err)
+	}
+
+	b := NewBuilder()
+	b.AddRoute("kg", "osh", "1", []float64{40.5}, []float64{72.8})
+	db, _ := b.Build()
+	if _, err := db.RouteGPX(0); err == nil {
+		t.Error("expected error for route made by a Builder")
+	}
+}
@@ -0,0 +1,124 @@
+package routedb
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/google/flatbuffers/go"
+	"github.com/jeffallen/routedb/route"
+)
+
+// Serialize returns the whole database as a FlatBuffer holding a
+// RouteDb, whose routes are encoded as by Route, along with the
+// Checksum of the database.
+func (db *Db) Serialize() []byte {
+	b := flatbuffers.NewBuilder(db.EstimateSerializedSize())
+	offs := make([]flatbuffers.UOffsetT, len(db.routes))
+	for i, t := range db.routes {
+		offs[i] = db.buildRoute(b, t, t.pts)
+	}
+	route.RouteDbStartRoutesVector(b, len(offs))
+	for i := len(offs) - 1; i >= 0; i-- {
+		b.PrependUOffsetT(offs[i])
+	}
+	routes := b.EndVector(len(offs))
+
+	route.RouteDbStart(b)
+	route.RouteDbAddRoutes(b, routes)
+	route.RouteDbAddChecksum(b, db.Checksum())
+	b.Finish(route.RouteDbEnd(b))
+	return b.Bytes[b.Head():]
+}
+
+// EstimateSerializedSize returns an estimate of the size in bytes of
+// the FlatBuffer returned by Serialize, computed from the number of
+// routes, the lengths of their metadata and their number of
+// waypoints, without building it.
+func (db *Db) EstimateSerializedSize() int {
+	// Root offset, the RouteDb table with its checksum,
+	// its vtable, and the length of its routes vector.
+	n := 4 + 16 + 8 + 4
+	// The Routes share a vtable when they have the same fields, so
+	// count one for each set of fields present, by slot.
+	vtables := make(map[uint]bool)
+	for _, t := range db.routes {
+		country, city, _ := t.split()
+		name := t.name(db.locale)
+		// An offset in the routes vector, then the Route table:
+		// its offset to its vtable and those of the three strings
+		// and the path, which are always present.
+		n += 4 + 4 + 4*4
+		fields := uint(1<<0 | 1<<1 | 1<<2 | 1<<3)
+		for _, s := range []string{country, city, name} {
+			// The string, with its length and terminator padded
+			// to 4 bytes.
+			n += 4 + (len(s)+4)&^3
+		}
+		n += 4 + 8*len(t.pts)
+		inline := 0 // bytes of the scalars in the table
+		if t.circular {
+			fields |= 1 << 4
+			inline++
+		}
+		for k, s := range []string{t.vehicle, t.color, t.textColor} {
+			if s != "" {
+				fields |= 1 << uint(5+k)
+				n += 4 + 4 + (len(s)+4)&^3
+			}
+		}
+		if t.wheelchair != 0 {
+			fields |= 1 << 8
+			inline++
+		}
+		n += (inline + 3) &^ 3
+		vtables[fields] = true
+	}
+	for fields := range vtables {
+		// The vtable's size and the table's, then an offset for
+		// each slot up to the last present.
+		slots := 0
+		for ; fields != 0; fields >>= 1 {
+			slots++
+		}
+		n += (4 + 2*slots + 3) &^ 3
+	}
+	return n
+}
+
+// Checksum returns a hash of everything Serialize writes for every
+// route, in order: its metadata, its name in the locale set by
+// SetLocale, its vehicle type, its colors, its wheelchair
+// accessibility and its waypoints, rounded to microdegrees as in
+// Route. It is stable across loads of the same data, so a client
+// caching the output of Serialize, which includes it, can compare it
+// with the database's to tell whether the cache is stale.
+func (db *Db) Checksum() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	str := func(s string) {
+		binary.LittleEndian.PutUint32(buf[:4], uint32(len(s)))
+		h.Write(buf[:4])
+		h.Write([]byte(s))
+	}
+	for _, t := range db.routes {
+		// The metadata name cannot tell apart all the ways of
+		// splitting it given in metadata.json.
+		country, city, _ := t.split()
+		str(t.md)
+		str(country)
+		str(city)
+		str(t.name(db.locale))
+		str(t.vehicle)
+		str(t.color)
+		str(t.textColor)
+		h.Write([]byte{byte(t.wheelchair)})
+		binary.LittleEndian.PutUint32(buf[:4], uint32(len(t.pts)))
+		h.Write(buf[:4])
+		for _, pt := range t.pts {
+			binary.LittleEndian.PutUint32(buf[:4], uint32(micro(pt.Lat)))
+			binary.LittleEndian.PutUint32(buf[4:], uint32(micro(pt.Lon)))
+			h.Write(buf[:])
+		}
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,103 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestSerialize(t *testing.T) {
+	db := loadTestdata(t, "testdata/cities.zip")
+	buf := db.Serialize()
+	rdb := route.GetRootAsRouteDb(buf, 0)
+	if rdb.RoutesLength() != db.Routes() {
+		t.Fatalf("got %v routes, expected %v", rdb.RoutesLength(), db.Routes())
+	}
+	r := &route.Route{}
+	rdb.Routes(r, 2)
+	if string(r.City()) != "bishkek" || string(r.Name()) != "5" || r.PathLength() != 2 {
+		t.Errorf("unexpected route %v/%v with %v points", string(r.City()), string(r.Name()), r.PathLength())
+	}
+}
+
+func TestEstimateSerializedSize(t *testing.T) {
+	// Routes with the optional fields of Route, in different
+	// combinations, so they need vtables of their own.
+	fdb, err := Load(zipFiles(t,
+		"a.gpx", plainGPX("kg-osh-1"),
+		"b.gpx", plainGPX("kg-osh-2"),
+		"c.gpx", plainGPX("kg-osh-3"),
+		"d.gpx", `<gpx><metadata><name>kg-osh-4</name></metadata><trk><trkseg>
+<trkpt lat="40.5" lon="72.80"/><trkpt lat="40.5" lon="72.81"/><trkpt lat="40.51" lon="72.81"/>
+<trkpt lat="40.51" lon="72.80"/><trkpt lat="40.5001" lon="72.80"/></trkseg></trk></gpx>`,
+		metadataFile, `{"routes": {
+"a": {"vehicle": "bus", "color": "FF0000", "wheelchair": "yes"},
+"b": {"text_color": "FFFFFF"}}}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, db := range []*Db{db, loadTestdata(t, "testdata/cities.zip"), &Db{}, fdb} {
+		est, real := db.EstimateSerializedSize(), len(db.Serialize())
+		if math.Abs(float64(est-real)) > 0.02*float64(real) {
+			t.Errorf("estimate %v is not within 2%% of %v", est, real)
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	a := loadTestdata(t, "testdata/cities.zip")
+	b := loadTestdata(t, "testdata/cities.zip")
+	if a.Checksum() != b.Checksum() {
+		t.Errorf("checksums of the same data differ: %x, %x", a.Checksum(), b.Checksum())
+	}
+	if a.Checksum() == db.Checksum() {
+		t.Errorf("checksums of different data are both %x", a.Checksum())
+	}
+	if c := route.GetRootAsRouteDb(a.Serialize(), 0).Checksum(); c != a.Checksum() {
+		t.Errorf("serialized checksum %x, expected %x", c, a.Checksum())
+	}
+
+	sum := b.Checksum()
+	b.routes[1].pts[0].Lat += 0.0001
+	if b.Checksum() == sum {
+		t.Error("checksum unchanged by moving a waypoint")
+	}
+	b.routes[1].pts[0].Lat -= 0.0001
+	if b.Checksum() != sum {
+		t.Error("checksum changed by moving a waypoint back")
+	}
+
+	// Every field written by Serialize changes the checksum.
+	for _, change := range []struct {
+		field string
+		set   func(t *track)
+	}{
+		{"vehicle", func(t *track) { t.vehicle = "tram" }},
+		{"color", func(t *track) { t.color = "FF0000" }},
+		{"text color", func(t *track) { t.textColor = "FFFFFF" }},
+		{"wheelchair", func(t *track) { t.wheelchair = WheelchairAccessible }},
+	} {
+		c := loadTestdata(t, "testdata/cities.zip")
+		sum := c.Checksum()
+		change.set(c.routes[1])
+		if c.Checksum() == sum {
+			t.Errorf("checksum unchanged by changing the %v", change.field)
+		}
+	}
+
+	// As does the name, when it is localized.
+	c := loadTestdata(t, "testdata/cities.zip")
+	c.routes[1].names = map[string]string{"ru": "Маршрут 5"}
+	sum = c.Checksum()
+	c.SetLocale("ru")
+	if c.Checksum() == sum {
+		t.Error("checksum unchanged by changing the locale")
+	}
+	sum = c.Checksum()
+	c.routes[1].names["ru"] = "Маршрут 6"
+	if c.Checksum() == sum {
+		t.Error("checksum unchanged by changing the localized name")
+	}
+}
@@ -0,0 +1,201 @@
+package routedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+)
+
+// metadataFile is the name of the file in a routedb zip holding the
+// metadata of its routes.
+const metadataFile = "metadata.json"
+
+// A routeMetadata is the metadata of a route given in JSON, rather
+// than packed into the name in its GPX metadata as country-city-name,
+// which cannot hold a city with a dash in its name, nor anything but
+// the three parts.
+//
+// The metadata of the routes may be given in the file metadata.json in
+// the routedb zip, with the agencies, if they are not in agencies.json,
+// and the metadata of each route by the name of its GPX file, with or
+// without its extension:
+//
+//	{"agencies": [{"id": "oshbus", "name": "Osh Bus"}],
+//	 "routes": {"7.gpx": {"id": "osh-7", "country": "kg",
+//	   "city": "osh", "name": "7", "vehicle": "bus",
+//	   "color": "00A0E0", "text_color": "FFFFFF", "agency": "oshbus",
+//	   "fare": {"currency": "KGS", "flat": 10}, "wheelchair": "yes",
+//	   "names": {"ru": "Маршрут 7"}}}}
+//
+// The metadata of a route may also be given in a file of its own, named
+// like its GPX file but with the extension .json, holding the object
+// given for it in "routes" above. That overrides metadata.json, which
+// overrides the GPX file. The fields not given are left as in the GPX
+// file, so an archive without any metadata loads as before.
+type routeMetadata struct {
+	ID         string            `json:"id"`
+	Country    string            `json:"country"`
+	City       string            `json:"city"`
+	Name       string            `json:"name"`
+	Vehicle    string            `json:"vehicle"`
+	Color      string            `json:"color"`
+	TextColor  string            `json:"text_color"`
+	Agency     string            `json:"agency"`
+	Fare       *Fare             `json:"fare"`
+	Wheelchair string            `json:"wheelchair"`
+	Names      map[string]string `json:"names"`
+
+	file string // the name of the GPX file it is for
+	own  bool   // given in a file of its own
+}
+
+// A routeKey is the country, city and name of a route, when given
+// apart rather than as its metadata name.
+type routeKey struct {
+	country, city, name string
+}
+
+// split returns the country, city and name of t.
+func (t *track) split() (country, city, name string) {
+	if t.key != nil {
+		return t.key.country, t.key.city, t.key.name
+	}
+	return split_md(t.md)
+}
+
+// setKey sets the country, city and name of t, and its metadata name
+// to match.
+func (t *track) setKey(country, city, name string) {
+	t.key = &routeKey{country, city, name}
+	t.md = country + "-" + city + "-" + name
+}
+
+// parseMetadata parses the metadata file fn, read from r, adding its
+// agencies to those in agencies and returning the metadata of its
+// routes in order of file name.
+func parseMetadata(fn string, r io.Reader, agencies map[string]*Agency) ([]*routeMetadata, error) {
+	var m struct {
+		Agencies []*Agency                 `json:"agencies"`
+		Routes   map[string]*routeMetadata `json:"routes"`
+	}
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", fn, err)
+	}
+	if err := addAgencies(fn, m.Agencies, agencies); err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range m.Routes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var list []*routeMetadata
+	for _, name := range names {
+		if rm := m.Routes[name]; rm != nil {
+			rm.file = name
+			list = append(list, rm)
+		}
+	}
+	return list, nil
+}
+
+// parseRouteMetadata parses the metadata file fn of a single route,
+// read from r.
+func parseRouteMetadata(fn string, r io.Reader) (*routeMetadata, error) {
+	rm := &routeMetadata{file: strings.TrimSuffix(fn, ".json"), own: true}
+	if err := json.NewDecoder(r).Decode(rm); err != nil {
+		return nil, fmt.Errorf("Failed to parse %v: %v", fn, err)
+	}
+	return rm, nil
+}
+
+// applyMetadata applies metadata to the tracks read from the GPX files
+// by name in files, with a warning for the metadata of a file not
+// found. It is an error for two routes to be given the same ID.
+func (db *Db) applyMetadata(metadata []*routeMetadata, files map[string]*track) error {
+	byName := make(map[string]*track)
+	for fn, t := range files {
+		byName[strings.TrimSuffix(fn, path.Ext(fn))] = t
+	}
+	for fn, t := range files {
+		byName[fn] = t
+	}
+	for _, own := range []bool{false, true} {
+		for _, rm := range metadata {
+			if rm.own != own {
+				continue
+			}
+			t := byName[rm.file]
+			if t == nil {
+				db.warnings = append(db.warnings, fmt.Sprintf("Metadata for unknown route file %v", rm.file))
+				continue
+			}
+			rm.apply(t)
+		}
+	}
+	ids := make(map[string]bool)
+	for _, t := range db.routes {
+		if t.id == "" {
+			continue
+		}
+		if ids[t.id] {
+			return fmt.Errorf("Route id %v is repeated in the metadata", t.id)
+		}
+		ids[t.id] = true
+	}
+	return nil
+}
+
+// apply sets the fields of t given in rm. Values which cannot be
+// understood are ignored.
+func (rm *routeMetadata) apply(t *track) {
+	if id := strings.TrimSpace(rm.ID); id != "" {
+		t.id, t.idGiven = id, true
+	}
+	if rm.Country != "" || rm.City != "" || rm.Name != "" {
+		country, city, name := t.split()
+		if rm.Country != "" {
+			country = strings.TrimSpace(rm.Country)
+		}
+		if rm.City != "" {
+			city = strings.TrimSpace(rm.City)
+		}
+		if rm.Name != "" {
+			name = strings.TrimSpace(rm.Name)
+		}
+		t.setKey(country, city, name)
+	}
+	if rm.Vehicle != "" {
+		t.vehicle = normalizeVehicle(rm.Vehicle)
+	}
+	if c := normalizeColor(rm.Color); c != "" {
+		t.color = c
+	}
+	if c := normalizeColor(rm.TextColor); c != "" {
+		t.textColor = c
+	}
+	if a := strings.TrimSpace(rm.Agency); a != "" {
+		t.agencyID = a
+	}
+	if rm.Fare != nil {
+		f := *rm.Fare
+		f.Currency = strings.ToUpper(strings.TrimSpace(f.Currency))
+		t.fare = &f
+	}
+	if w := parseWheelchair(rm.Wheelchair); w != WheelchairUnknown {
+		t.wheelchair = w
+	}
+	var ns []gpxName
+	for lang, name := range rm.Names {
+		ns = append(ns, gpxName{Lang: lang, Name: name})
+	}
+	for lang, name := range localizedNames(ns) {
+		if t.names == nil {
+			t.names = make(map[string]string)
+		}
+		t.names[lang] = name
+	}
+}
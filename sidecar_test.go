@@ -0,0 +1,134 @@
+package routedb
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+// plainGPX returns a GPX file for a route with metadata name md and
+// nothing else.
+func plainGPX(md string) string {
+	return `<gpx><metadata><name>` + md + `</name></metadata>
+<trk><trkseg><trkpt lat="40.5" lon="72.8"/></trkseg></trk></gpx>`
+}
+
+func TestMetadataFile(t *testing.T) {
+	sdb, err := Load(zipFiles(t,
+		"7.gpx", plainGPX("kg-osh-7"),
+		"kb.gpx", plainGPX(""),
+		"8.gpx", agencyGPX("oshbus"),
+		"plain.gpx", plainGPX("kg-osh-9"),
+		metadataFile, `{
+"agencies": [{"id": "oshbus", "name": "Osh Bus"}],
+"routes": {
+ "7.gpx": {"id": "osh-7", "color": "#00a0e0", "text_color": "FFFFFF",
+  "vehicle": "Bus", "agency": "oshbus", "wheelchair": "yes",
+  "fare": {"currency": "kgs", "flat": 10, "per_km": 1.5},
+  "names": {"ru": "Маршрут 7"}},
+ "kb": {"country": "kg", "city": "Kara-Balta", "name": "1"},
+ "8.gpx": {"name": "8"},
+ "missing.gpx": {"name": "x"}
+}}`,
+		"8.json", `{"id": "osh-8", "wheelchair": "no"}`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if id, _ := sdb.RouteID(0); id != "osh-7" {
+		t.Errorf("route 0 id is %v", id)
+	}
+	if c, tc, _ := sdb.RouteColors(0); c != "00A0E0" || tc != "FFFFFF" {
+		t.Errorf("route 0 colors are %v, %v", c, tc)
+	}
+	if v, _ := sdb.RouteVehicle(0); v != "bus" {
+		t.Errorf("route 0 vehicle is %v", v)
+	}
+	if a, err := sdb.Agency(0); err != nil || a.Name != "Osh Bus" {
+		t.Errorf("route 0 agency is %v, %v", a, err)
+	}
+	if f, err := sdb.Fare(0); err != nil || *f != (Fare{"KGS", 10, 1.5, 0}) {
+		t.Errorf("route 0 fare is %v, %v", f, err)
+	}
+	if w, _ := sdb.RouteWheelchair(0); w != WheelchairAccessible {
+		t.Errorf("route 0 wheelchair is %v", w)
+	}
+	if n, _ := sdb.RouteName(0, "ru"); n != "Маршрут 7" {
+		t.Errorf("route 0 name in ru is %v", n)
+	}
+
+	// A city with a dash in its name, which the metadata name
+	// cannot hold.
+	buf, err := sdb.Route(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := route.GetRootAsRoute(buf, 0)
+	if string(r.Country()) != "kg" || string(r.City()) != "Kara-Balta" || string(r.Name()) != "1" {
+		t.Errorf("route 1 is %s-%s-%s", r.Country(), r.City(), r.Name())
+	}
+	if routes := sdb.RoutesInCity("Kara-Balta"); len(routes) != 1 || routes[0] != 1 {
+		t.Errorf("routes in Kara-Balta are %v", routes)
+	}
+
+	// The metadata of a route in a file of its own overrides
+	// metadata.json, which overrides the GPX file, and the fields
+	// not given are left as they were.
+	if id, _ := sdb.RouteID(2); id != "osh-8" {
+		t.Errorf("route 2 id is %v", id)
+	}
+	if _, _, name := sdb.routes[2].split(); name != "8" {
+		t.Errorf("route 2 name is %v", name)
+	}
+	if a, err := sdb.Agency(2); err != nil || a.ID != "oshbus" {
+		t.Errorf("route 2 agency is %v, %v", a, err)
+	}
+	if w, _ := sdb.RouteWheelchair(2); w != WheelchairInaccessible {
+		t.Errorf("route 2 wheelchair is %v", w)
+	}
+
+	// Routes without metadata load as before.
+	if id, _ := sdb.RouteID(3); id != "kg-osh-9" {
+		t.Errorf("route 3 id is %v", id)
+	}
+	if !strings.Contains(sdb.Warnings(), "missing.gpx") {
+		t.Errorf("expected warning for missing.gpx, got %q", sdb.Warnings())
+	}
+}
+
+// TestMetadataChecksum checks that routes whose metadata names are
+// the same, but which are split differently by metadata.json, have
+// different checksums, as they serialize differently.
+func TestMetadataChecksum(t *testing.T) {
+	var sums []uint64
+	for _, meta := range []string{
+		`{"country": "kg", "city": "Kara-Balta", "name": "1"}`,
+		`{"country": "kg", "city": "Kara", "name": "Balta-1"}`,
+	} {
+		sdb, err := Load(zipFiles(t, "1.gpx", plainGPX(""), "1.json", meta))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sums = append(sums, sdb.Checksum())
+	}
+	if sums[0] == sums[1] {
+		t.Errorf("checksums are both %x", sums[0])
+	}
+}
+
+func TestMetadataFileErrors(t *testing.T) {
+	for _, files := range [][]string{
+		{"1.gpx", plainGPX("kg-osh-1"), metadataFile, `[]`},
+		{"1.gpx", plainGPX("kg-osh-1"), "1.json", `{"id": 1}`},
+		{"1.gpx", plainGPX("kg-osh-1"), "2.gpx", plainGPX("kg-osh-2"),
+			metadataFile, `{"routes": {"1": {"id": "a"}, "2": {"id": "a"}}}`},
+		{"1.gpx", plainGPX("kg-osh-1"), agenciesFile, `[{"id": "a"}]`,
+			metadataFile, `{"agencies": [{"id": "a"}]}`},
+	} {
+		if _, err := Load(zipFiles(t, files...)); err == nil {
+			t.Errorf("%v: expected error", files)
+		}
+	}
+}
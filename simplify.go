@@ -0,0 +1,218 @@
+package routedb
+
+import "errors"
+
+// decimate returns k of pts, evenly spaced by index and including the
+// first and last. If pts has no more than k points, they are all
+// returned. The first and last are kept even for k less than 2, so
+// then two points are returned.
+func decimate(pts []Stop, k int) []Stop {
+	n := len(pts)
+	if k < 2 {
+		k = 2
+	}
+	if n <= k {
+		return pts
+	}
+	out := make([]Stop, k)
+	for j := 0; j < k; j++ {
+		out[j] = pts[j*(n-1)/(k-1)]
+	}
+	return out
+}
+
+// RouteCapped is like Route, but the path is evenly decimated to at
+// most maxPoints waypoints, keeping the first and last. This bounds
+// the size of the FlatBuffer however dense the route. A maxPoints of
+// 0 or less means no limit, and one of 1 gives the first and last
+// waypoints, as there are always both.
+func (db *Db) RouteCapped(i int, maxPoints int) ([]byte, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	pts := t.pts
+	if maxPoints > 0 {
+		pts = decimate(pts, maxPoints)
+	}
+	return db.finishRoute(t, pts), nil
+}
+
+// RouteSampled returns k waypoints of route i, evenly spaced by index
+// along the route and always including the first and last, so a k less
+// than 2 gives those two. If the route has no more than k waypoints,
+// they are all returned.
+func (db *Db) RouteSampled(i int, k int) ([]*Stop, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	pts := decimate(t.pts, k)
+	stops := make([]*Stop, len(pts))
+	for j, pt := range pts {
+		stops[j] = &Stop{Lat: pt.Lat, Lon: pt.Lon}
+	}
+	return stops, nil
+}
+
+// RemoveColinear deletes from every route each waypoint lying within
+// toleranceMeters of the straight line from the last waypoint kept
+// before it to the one after it, provided all the waypoints deleted
+// since the last one kept lie within toleranceMeters of that line too,
+// so that runs of points along a straight road collapse to their ends
+// while turns survive. The first and last waypoints of each route are
+// always kept. Checking all the points deleted since the last kept one
+// stops many small deviations adding up to a visible change of shape,
+// as they would along a gentle curve or a densely sampled turn.
+//
+// Like all the methods changing the database, it must not be called
+// concurrently with queries.
+func (db *Db) RemoveColinear(toleranceMeters float64) {
+	changed := false
+	for _, t := range db.routes {
+		if removeColinear(t, toleranceMeters) {
+			t.update()
+			changed = true
+		}
+	}
+	if changed {
+		db.RecomputeBounds()
+	}
+}
+
+// removeColinear removes the colinear waypoints of t, reporting
+// whether there were any.
+func removeColinear(t *track, tol float64) bool {
+	if len(t.pts) < 3 {
+		return false
+	}
+	// within reports whether the waypoints between a and b all lie
+	// within tol of the line from a to b.
+	within := func(a, b int) bool {
+		for m := a + 1; m < b; m++ {
+			q, _ := project(t.pts[m], t.pts[a], t.pts[b])
+			if distance(t.pts[m], q) > tol {
+				return false
+			}
+		}
+		return true
+	}
+	pts := []Stop{t.pts[0]}
+	var ele []float64
+	if t.ele != nil {
+		ele = []float64{t.ele[0]}
+	}
+	last := 0 // index of the last waypoint kept
+	for j := 1; j < len(t.pts); j++ {
+		if j+1 < len(t.pts) && within(last, j+1) {
+			continue
+		}
+		pts = append(pts, t.pts[j])
+		if t.ele != nil {
+			ele = append(ele, t.ele[j])
+		}
+		last = j
+	}
+	if len(pts) == len(t.pts) {
+		return false
+	}
+	t.pts, t.ele = pts, ele
+	return true
+}
+
+// douglasPeucker returns pts simplified with the Douglas-Peucker
+// algorithm: the fewest of them, always including the first and last,
+// such that no point left out is further than tol meters from the
+// path through those kept.
+func douglasPeucker(pts []Stop, tol float64) []Stop {
+	if len(pts) < 3 {
+		return pts
+	}
+	keep := make([]bool, len(pts))
+	keep[0], keep[len(pts)-1] = true, true
+	var mark func(lo, hi int)
+	mark = func(lo, hi int) {
+		far, dmax := -1, tol
+		for j := lo + 1; j < hi; j++ {
+			q, _ := project(pts[j], pts[lo], pts[hi])
+			if d := distance(pts[j], q); d > dmax {
+				far, dmax = j, d
+			}
+		}
+		if far >= 0 {
+			keep[far] = true
+			mark(lo, far)
+			mark(far, hi)
+		}
+	}
+	mark(0, len(pts)-1)
+
+	var out []Stop
+	for j, pt := range pts {
+		if keep[j] {
+			out = append(out, pt)
+		}
+	}
+	return out
+}
+
+// RouteSimplified is like Route, but the path is simplified with the
+// Douglas-Peucker algorithm so that it strays no more than
+// toleranceMeters from the original, which is plenty for drawing the
+// route at low zoom, at a fraction of the size. The first and last
+// waypoints are always kept.
+func (db *Db) RouteSimplified(i int, toleranceMeters float64) ([]byte, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	return db.finishRoute(t, douglasPeucker(t.pts, toleranceMeters)), nil
+}
+
+// minSpacingMeters is the least spacing accepted for resampling, which
+// bounds the number of points made to one for each meter of path.
+const minSpacingMeters = 1
+
+// errSpacing is the error returned for a spacing below
+// minSpacingMeters.
+var errSpacing = errors.New("spacing must be at least 1 meter")
+
+// resample returns points every spacing meters along the path pts,
+// starting with its first point, and ending with its last.
+func resample(pts []Stop, spacing float64) []Stop {
+	if len(pts) == 0 {
+		return nil
+	}
+	out := []Stop{pts[0]}
+	next := spacing // distance along the path of the next point
+	done := 0.0     // distance along the path of the segment start
+	for j := 1; j < len(pts); j++ {
+		a, b := pts[j-1], pts[j]
+		l := distance(a, b)
+		for ; next < done+l; next += spacing {
+			out = append(out, interpolate(a, b, (next-done)/l))
+		}
+		done += l
+	}
+	if last := pts[len(pts)-1]; out[len(out)-1] != last {
+		out = append(out, last)
+	}
+	return out
+}
+
+// RouteResampled is like Route, but the path is resampled to have a
+// waypoint every spacingMeters along it, which suits map matching and
+// animation better than the uneven spacing of raw GPS fixes. The
+// first and last waypoints are kept, so the last gap may be shorter.
+// To bound the size of the result, spacingMeters must be at least
+// minSpacingMeters.
+func (db *Db) RouteResampled(i int, spacingMeters float64) ([]byte, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	if !(spacingMeters >= minSpacingMeters) {
+		return nil, errSpacing
+	}
+	return db.finishRoute(t, resample(t.pts, spacingMeters)), nil
+}
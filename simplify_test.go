@@ -0,0 +1,250 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestRouteCapped(t *testing.T) {
+	pts := db.routes[0].pts
+	first := route.GeoPoint{}
+	last := route.GeoPoint{}
+	for _, max := range []int{1, 2, 10, 100, 476, 477, 1000, 0} {
+		buf, err := db.RouteCapped(0, max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := route.GetRootAsRoute(buf, 0)
+		n := r.PathLength()
+		if max > 1 && n > max || max == 1 && n != 2 || max <= 0 && n != len(pts) {
+			t.Errorf("cap %v gave %v points", max, n)
+		}
+		if max >= len(pts) && n != len(pts) {
+			t.Errorf("cap %v gave %v points, expected all %v", max, n, len(pts))
+		}
+		r.Path(&first, 0)
+		r.Path(&last, n-1)
+		if first.Lat() != micro(pts[0].Lat) || last.Lat() != micro(pts[len(pts)-1].Lat) {
+			t.Errorf("cap %v did not keep the endpoints", max)
+		}
+	}
+
+	if _, err := db.RouteCapped(1, 10); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRouteSampled(t *testing.T) {
+	pts := db.routes[0].pts
+	stops, err := db.RouteSampled(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stops) != 10 {
+		t.Fatalf("got %v stops, expected 10", len(stops))
+	}
+	if *stops[0] != pts[0] || *stops[9] != pts[len(pts)-1] {
+		t.Error("endpoints not kept")
+	}
+	// 476 segments split 9 ways.
+	for j, s := range stops {
+		if *s != pts[j*476/9] {
+			t.Errorf("stop %v is %v, expected point %v", j, *s, j*476/9)
+		}
+	}
+
+	// The endpoints are kept however few points are asked for.
+	for _, k := range []int{-1, 0, 1, 2} {
+		stops, err := db.RouteSampled(0, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stops) != 2 || *stops[0] != pts[0] || *stops[1] != pts[len(pts)-1] {
+			t.Errorf("%v samples are %v", k, stops)
+		}
+	}
+	b := NewBuilder()
+	b.AddRoute("kg", "osh", "1", []float64{40.5}, []float64{72.8})
+	bdb, _ := b.Build()
+	if stops, _ := bdb.RouteSampled(0, 1); len(stops) != 1 {
+		t.Errorf("single point route gave %v samples", len(stops))
+	}
+
+	stops, err = db.RouteSampled(0, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stops) != len(pts) {
+		t.Errorf("got %v stops, expected all %v", len(stops), len(pts))
+	}
+
+	if _, err := db.RouteSampled(1, 10); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRemoveColinear(t *testing.T) {
+	db := makeDb(t,
+		// East along a street, with two points on the way and one
+		// a meter off it, then a turn north with one point on the
+		// way.
+		testRoute{"kg-osh-1", []float64{
+			40.50, 72.80,
+			40.50, 72.801,
+			40.50001, 72.802,
+			40.50, 72.803,
+			40.501, 72.803,
+			40.502, 72.803,
+		}},
+		testRoute{"kg-osh-2", []float64{40.50, 72.80, 40.50, 72.801}},
+	)
+	length, _ := db.RouteLength(0)
+
+	db.RemoveColinear(2)
+	want := []Stop{{40.50, 72.80}, {40.50, 72.803}, {40.502, 72.803}}
+	got := db.routes[0].pts
+	if len(got) != len(want) {
+		t.Fatalf("route is %v, expected %v", got, want)
+	}
+	for j := range want {
+		if got[j] != want[j] {
+			t.Errorf("point %v is %v, expected %v", j, got[j], want[j])
+		}
+	}
+	if n, _ := db.RoutePointCount(1); n != 2 {
+		t.Errorf("two point route has %v points", n)
+	}
+	if l, _ := db.RouteLength(0); l > length || l < length-1 {
+		t.Errorf("length %v, expected just under %v", l, length)
+	}
+	if db.Bounds().N != 40.502 {
+		t.Errorf("bounds %v not recomputed", *db.Bounds())
+	}
+
+	db.RemoveColinear(0.1)
+	if n, _ := db.RoutePointCount(0); n != 3 {
+		t.Errorf("turn removed, %v points left", n)
+	}
+}
+
+// TestRemoveColinearDense checks that the shape of densely sampled
+// turns and curves survives, however close together their points.
+func TestRemoveColinearDense(t *testing.T) {
+	const step = 5.0 // meters between points
+	dLat := step / metersPerDegree
+	dLon := dLat / math.Cos(40.5*math.Pi/180)
+	// An L: east, then north from the corner.
+	var l []float64
+	for j := 0; j <= 10; j++ {
+		l = append(l, 40.5, 72.8+float64(j)*dLon)
+	}
+	for j := 1; j <= 10; j++ {
+		l = append(l, 40.5+float64(j)*dLat, 72.8+10*dLon)
+	}
+	// A quarter circle of radius 100 m.
+	var arc []float64
+	for a := 0; a <= 90; a += 3 {
+		r := 100 / metersPerDegree
+		arc = append(arc, 40.5+r*math.Sin(float64(a)*math.Pi/180), 72.8+r*math.Cos(float64(a)*math.Pi/180)/math.Cos(40.5*math.Pi/180))
+	}
+	sdb := makeDb(t, testRoute{"kg-osh-1", l}, testRoute{"kg-osh-2", arc})
+	orig := [][]Stop{sdb.routes[0].pts, sdb.routes[1].pts}
+
+	sdb.RemoveColinear(step * 2)
+	for i, pts := range orig {
+		got := sdb.routes[i].pts
+		if len(got) >= len(pts) || len(got) < 3 {
+			t.Errorf("route %v: %v points left of %v", i, len(got), len(pts))
+		}
+		for j, p := range pts {
+			if _, _, _, d := snap(p, got); d > step*2+0.01 {
+				t.Errorf("route %v: point %v is %v m off the simplified path", i, j, d)
+			}
+		}
+	}
+	// The corner may be cut, but only by the tolerance.
+	corner := Stop{40.5, 72.8 + 10*dLon}
+	if _, _, _, d := snap(corner, sdb.routes[0].pts); d > step*2+0.01 {
+		t.Errorf("corner is %v m off the simplified path", d)
+	}
+}
+
+func TestRouteSimplified(t *testing.T) {
+	pts := db.routes[0].pts
+	prev := len(pts) + 1
+	for _, tol := range []float64{0, 1, 10, 100, 1000} {
+		buf, err := db.RouteSimplified(0, tol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := route.GetRootAsRoute(buf, 0)
+		n := r.PathLength()
+		if n > prev || n < 2 {
+			t.Errorf("tolerance %v gave %v points, after %v", tol, n, prev)
+		}
+		prev = n
+
+		// Every original point is within the tolerance of the
+		// simplified path.
+		simple := douglasPeucker(pts, tol)
+		if len(simple) != n || simple[0] != pts[0] || simple[n-1] != pts[len(pts)-1] {
+			t.Errorf("tolerance %v did not keep the ends", tol)
+		}
+		for _, pt := range pts {
+			if _, _, _, d := snap(pt, simple); d > tol+0.01 {
+				t.Errorf("tolerance %v left %v %v meters away", tol, pt, d)
+			}
+		}
+	}
+	if prev > 10 {
+		t.Errorf("1 km tolerance kept %v points", prev)
+	}
+	if _, err := db.RouteSimplified(1, 10); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
+
+func TestRouteResampled(t *testing.T) {
+	pts := db.routes[0].pts
+	length, _ := db.RouteLength(0)
+	for _, spacing := range []float64{25, 100, 1000, 1e6} {
+		got := resample(pts, spacing)
+		if want := int(math.Ceil(length/spacing)) + 1; len(got) != want {
+			t.Errorf("spacing %v gave %v points, expected %v", spacing, len(got), want)
+		}
+		if got[0] != pts[0] || got[len(got)-1] != pts[len(pts)-1] {
+			t.Errorf("spacing %v did not keep the ends", spacing)
+		}
+		// Each point lies on the path, at the right distance along
+		// it.
+		for k, p := range got[:len(got)-1] {
+			if _, _, _, d := snap(p, pts); d > 0.5 {
+				t.Errorf("spacing %v: point %v is %v meters off the path", spacing, k, d)
+			}
+		}
+		if k := 3; len(got) > k+1 {
+			m, _, _ := db.RouteProgress(0, got[k].Lat, got[k].Lon)
+			if math.Abs(m-float64(k)*spacing) > 1 {
+				t.Errorf("spacing %v: point %v is %v meters along, expected %v", spacing, k, m, float64(k)*spacing)
+			}
+		}
+	}
+
+	buf, err := db.RouteResampled(0, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := route.GetRootAsRoute(buf, 0).PathLength(); n != int(math.Ceil(length/25))+1 {
+		t.Errorf("got %v points", n)
+	}
+	for _, spacing := range []float64{0, 0.001, math.NaN()} {
+		if _, err := db.RouteResampled(0, spacing); err == nil {
+			t.Errorf("expected error for spacing %v", spacing)
+		}
+	}
+	if _, err := db.RouteResampled(1, 25); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package routedb
+
+import (
+	"math"
+	"sort"
+)
+
+// RouteStopsSpaced returns the waypoints of route i, thinned so that
+// no two consecutive stops are closer than minMeters. The first and
+// last waypoints are always kept; the original points are returned
+// unchanged, only skipped.
+func (db *Db) RouteStopsSpaced(i int, minMeters float64) ([]*Stop, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	n := len(t.pts)
+	if n == 0 {
+		return []*Stop{}, nil
+	}
+
+	stops := []*Stop{&Stop{t.pts[0].Lat, t.pts[0].Lon}}
+	for j := 1; j < n-1; j++ {
+		if distance(*stops[len(stops)-1], t.pts[j]) >= minMeters {
+			stops = append(stops, &Stop{t.pts[j].Lat, t.pts[j].Lon})
+		}
+	}
+	if n > 1 {
+		// Make room for the last point by dropping kept points
+		// that crowd it, but never the first one.
+		last := t.pts[n-1]
+		for len(stops) > 1 && distance(*stops[len(stops)-1], last) < minMeters {
+			stops = stops[:len(stops)-1]
+		}
+		stops = append(stops, &Stop{last.Lat, last.Lon})
+	}
+	return stops, nil
+}
+
+// RoutesAtStop returns the indices of the routes with a waypoint
+// within toleranceMeters of stop.
+func (db *Db) RoutesAtStop(stop *Stop, toleranceMeters float64) []int {
+	routes := []int{}
+	for i, t := range db.routes {
+		for _, pt := range t.pts {
+			if distance(*stop, pt) <= toleranceMeters {
+				routes = append(routes, i)
+				break
+			}
+		}
+	}
+	return routes
+}
+
+// UniqueStops returns the physical locations of the waypoints of all
+// the routes, with waypoints within toleranceMeters of one another,
+// such as those of routes sharing a corridor, collapsed into one.
+//
+// Waypoints are clustered greedily in route order: each joins the
+// first cluster found within toleranceMeters of it, or else starts a
+// new one. Clusters are found with a grid of squares toleranceMeters
+// on a side, searching only the waypoint's own square and its
+// neighbours. Each cluster is represented by its first waypoint.
+func (db *Db) UniqueStops(toleranceMeters float64) []*Stop {
+	reps := db.clusterStops(toleranceMeters, nil)
+	stops := make([]*Stop, len(reps))
+	for k, s := range reps {
+		stops[k] = &Stop{Lat: s.Lat, Lon: s.Lon}
+	}
+	return stops
+}
+
+// clusterStops clusters the waypoints of all the routes as described
+// for UniqueStops, returning the representative of each cluster. If
+// visit is not nil, it is called for each waypoint with the index of
+// its cluster and of its route.
+func (db *Db) clusterStops(toleranceMeters float64, visit func(c, routeIndex int)) []Stop {
+	var reps []Stop
+	exact := make(map[Stop]int)
+	grid := make(map[[2]int][]int)
+	k := math.Cos((db.bounds.N + db.bounds.S) / 2 * math.Pi / 180)
+
+	for i, t := range db.routes {
+		for _, pt := range t.pts {
+			c := -1
+			if toleranceMeters <= 0 {
+				if cc, ok := exact[pt]; ok {
+					c = cc
+				} else {
+					c = len(reps)
+					reps = append(reps, pt)
+					exact[pt] = c
+				}
+			} else {
+				x := int(math.Floor(pt.Lon * k * metersPerDegree / toleranceMeters))
+				y := int(math.Floor(pt.Lat * metersPerDegree / toleranceMeters))
+			search:
+				for dx := -1; dx <= 1; dx++ {
+					for dy := -1; dy <= 1; dy++ {
+						for _, cc := range grid[[2]int{x + dx, y + dy}] {
+							if distance(reps[cc], pt) <= toleranceMeters {
+								c = cc
+								break search
+							}
+						}
+					}
+				}
+				if c < 0 {
+					c = len(reps)
+					reps = append(reps, pt)
+					grid[[2]int{x, y}] = append(grid[[2]int{x, y}], c)
+				}
+			}
+			if visit != nil {
+				visit(c, i)
+			}
+		}
+	}
+	return reps
+}
+
+// A StopService is a stop and the number of routes serving it.
+type StopService struct {
+	Stop   *Stop
+	Routes int
+}
+
+// byRoutes sorts StopServices by descending number of routes.
+type byRoutes []StopService
+
+func (s byRoutes) Len() int           { return len(s) }
+func (s byRoutes) Less(i, j int) bool { return s[i].Routes > s[j].Routes }
+func (s byRoutes) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// StopServiceCounts returns each of the stops found by UniqueStops
+// with the number of distinct routes passing through it, busiest
+// first, to pick out the interchanges. Stops served by the same number
+// of routes stay in the order UniqueStops returns them.
+func (db *Db) StopServiceCounts(toleranceMeters float64) []StopService {
+	var counts []int
+	var last []int
+	reps := db.clusterStops(toleranceMeters, func(c, routeIndex int) {
+		if c == len(counts) {
+			counts = append(counts, 0)
+			last = append(last, -1)
+		}
+		// Waypoints are visited in route order, so a route
+		// returning to a stop is only counted once.
+		if last[c] != routeIndex {
+			counts[c]++
+			last[c] = routeIndex
+		}
+	})
+	out := make([]StopService, len(reps))
+	for c, s := range reps {
+		out[c] = StopService{&Stop{Lat: s.Lat, Lon: s.Lon}, counts[c]}
+	}
+	sort.Stable(byRoutes(out))
+	return out
+}
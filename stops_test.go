@@ -0,0 +1,104 @@
+package routedb
+
+import "testing"
+
+func TestRouteStopsSpaced(t *testing.T) {
+	min := 50.0
+	stops, err := db.RouteStopsSpaced(0, min)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stops) < 3 || len(stops) >= 477 {
+		t.Fatalf("unexpected number of stops: %v", len(stops))
+	}
+
+	pts := db.routes[0].pts
+	if *stops[0] != pts[0] || *stops[len(stops)-1] != pts[len(pts)-1] {
+		t.Error("endpoints not kept")
+	}
+	for j := 1; j < len(stops); j++ {
+		if d := distance(*stops[j-1], *stops[j]); d < min {
+			t.Errorf("stops %v and %v are %v m apart", j-1, j, d)
+		}
+	}
+
+	if _, err := db.RouteStopsSpaced(1, min); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRoutesAtStop(t *testing.T) {
+	var fixture []float64
+	for _, pt := range db.routes[0].pts {
+		fixture = append(fixture, pt.Lat, pt.Lon)
+	}
+	db := makeDb(t,
+		testRoute{"kg-osh-149", fixture},
+		testRoute{"kg-osh-far", []float64{40.6, 72.9, 40.61, 72.91}},
+		testRoute{"kg-osh-cross", []float64{40.51, 72.82, 40.50263, 72.821976, 40.49, 72.83}},
+	)
+
+	stop := &Stop{40.50263, 72.821976}
+	routes := db.RoutesAtStop(stop, 10)
+	if len(routes) != 2 || routes[0] != 0 || routes[1] != 2 {
+		t.Errorf("routes at stop are %v, expected [0 2]", routes)
+	}
+
+	routes = db.RoutesAtStop(&Stop{0, 0}, 10)
+	if routes == nil || len(routes) != 0 {
+		t.Errorf("expected no routes, got %v", routes)
+	}
+}
+
+func TestUniqueStops(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.5, 72.82, 40.5, 72.83}},
+		// Shares the middle two stops, give or take a meter.
+		testRoute{"kg-osh-2", []float64{40.49, 72.81, 40.50001, 72.81, 40.5, 72.82001, 40.51, 72.82}},
+	)
+
+	stops := db.UniqueStops(5)
+	if len(stops) != 6 || len(stops) >= db.TotalWaypoints() {
+		t.Errorf("got %v unique stops of %v, expected 6", len(stops), db.TotalWaypoints())
+	}
+	for j := range stops {
+		for k := j + 1; k < len(stops); k++ {
+			if d := distance(*stops[j], *stops[k]); d <= 5 {
+				t.Errorf("stops %v and %v are only %v m apart", *stops[j], *stops[k], d)
+			}
+		}
+	}
+
+	if n := len(db.UniqueStops(0)); n != 8 {
+		t.Errorf("got %v exactly unique stops, expected 8", n)
+	}
+}
+
+func TestStopServiceCounts(t *testing.T) {
+	db := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81, 40.5, 72.82}},
+		// Crosses kg-osh-1 at 40.5, 72.81, and returns to it.
+		testRoute{"kg-osh-2", []float64{40.49, 72.81, 40.50001, 72.81, 40.51, 72.81, 40.5, 72.81}},
+		testRoute{"kg-osh-3", []float64{40.5, 72.81, 40.5, 72.82}},
+	)
+
+	counts := db.StopServiceCounts(5)
+	if len(counts) != 5 {
+		t.Fatalf("got %v stops, expected 5", len(counts))
+	}
+	if s := counts[0]; *s.Stop != (Stop{40.5, 72.81}) || s.Routes != 3 {
+		t.Errorf("busiest stop is %v with %v routes, expected 40.5, 72.81 with 3", *s.Stop, s.Routes)
+	}
+	if s := counts[1]; *s.Stop != (Stop{40.5, 72.82}) || s.Routes != 2 {
+		t.Errorf("next stop is %v with %v routes, expected 40.5, 72.82 with 2", *s.Stop, s.Routes)
+	}
+	for _, s := range counts[2:] {
+		if s.Routes != 1 {
+			t.Errorf("stop %v has %v routes, expected 1", *s.Stop, s.Routes)
+		}
+	}
+
+	if counts := (&Db{}).StopServiceCounts(5); len(counts) != 0 {
+		t.Errorf("empty db has %v stops", len(counts))
+	}
+}
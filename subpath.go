@@ -0,0 +1,76 @@
+package routedb
+
+// subPath returns the part of the path pts between the points on it
+// nearest to from and to, starting and ending with those points. If
+// to comes before from along the path, the part is returned backwards,
+// running from from to to.
+func subPath(pts []Stop, from, to Stop) []Stop {
+	q1, s1, t1, _ := snap(from, pts)
+	q2, s2, t2, _ := snap(to, pts)
+	if s2 < s1 || s2 == s1 && t2 < t1 {
+		sub, _ := reversed(subPath(pts, to, from), nil)
+		return sub
+	}
+	sub := []Stop{q1}
+	for _, pt := range append(pts[s1+1:s2+1:s2+1], q2) {
+		if pt != sub[len(sub)-1] {
+			sub = append(sub, pt)
+		}
+	}
+	return sub
+}
+
+// RouteSubPath returns the part of route i between the points on its
+// path nearest to the two given points, such as where the user boards
+// and where they get off, as a FlatBuffer holding a Route encoded as
+// by Route. The path starts and ends with those nearest points, which
+// usually lie between waypoints. If the second point comes before the
+// first along the route, the path runs backwards.
+func (db *Db) RouteSubPath(i int, fromLat, fromLon, toLat, toLon float64) ([]byte, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLatLon(fromLat, fromLon); err != nil {
+		return nil, err
+	}
+	if err := checkLatLon(toLat, toLon); err != nil {
+		return nil, err
+	}
+	if len(t.pts) == 0 {
+		return nil, errEmptyRoute
+	}
+	return db.finishRoute(t, subPath(t.pts, Stop{fromLat, fromLon}, Stop{toLat, toLon})), nil
+}
+
+// DistanceBetween returns the distance in meters along the path of
+// route i between the points on it nearest to the two given points,
+// rather than the straight line distance between them, for fares and
+// travel times on winding routes. The distance is the same whichever
+// way round the points are given, except on a circular route, as told
+// by IsCircular, where the ride is always taken forwards: if the second
+// point comes before the first, it goes on round the loop, across the
+// gap closing it, and on to the second point.
+func (db *Db) DistanceBetween(routeIndex int, fromLat, fromLon, toLat, toLon float64) (float64, error) {
+	t, err := db.routeAt(routeIndex)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkLatLon(fromLat, fromLon); err != nil {
+		return 0, err
+	}
+	if err := checkLatLon(toLat, toLon); err != nil {
+		return 0, err
+	}
+	if len(t.pts) == 0 {
+		return 0, errEmptyRoute
+	}
+	from, to := Stop{fromLat, fromLon}, Stop{toLat, toLon}
+	if t.circular {
+		if a, b := alongPath(t.pts, from), alongPath(t.pts, to); b < a {
+			// Go round to the start of the loop, and on to to.
+			return b + t.length + distance(t.pts[len(t.pts)-1], t.pts[0]) - a, nil
+		}
+	}
+	return pathLength(subPath(t.pts, from, to)), nil
+}
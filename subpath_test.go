@@ -0,0 +1,116 @@
+package routedb
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestSubPath(t *testing.T) {
+	pts := []Stop{{40.5, 72.80}, {40.5, 72.81}, {40.51, 72.81}, {40.51, 72.82}}
+	for _, tc := range []struct {
+		from, to Stop
+		want     []Stop
+	}{
+		// Within one segment.
+		{Stop{40.5001, 72.802}, Stop{40.4999, 72.805}, []Stop{{40.5, 72.802}, {40.5, 72.805}}},
+		// Across two waypoints.
+		{Stop{40.5, 72.805}, Stop{40.51, 72.815}, []Stop{{40.5, 72.805}, {40.5, 72.81}, {40.51, 72.81}, {40.51, 72.815}}},
+		// Backwards.
+		{Stop{40.51, 72.815}, Stop{40.5, 72.805}, []Stop{{40.51, 72.815}, {40.51, 72.81}, {40.5, 72.81}, {40.5, 72.805}}},
+		// From a waypoint to beyond the end.
+		{Stop{40.5, 72.81}, Stop{40.52, 72.83}, []Stop{{40.5, 72.81}, {40.51, 72.81}, {40.51, 72.82}}},
+		// Both at the same place.
+		{Stop{40.5, 72.805}, Stop{40.5, 72.805}, []Stop{{40.5, 72.805}}},
+	} {
+		got := subPath(pts, tc.from, tc.to)
+		ok := len(got) == len(tc.want)
+		for k := 0; ok && k < len(got); k++ {
+			ok = distance(got[k], tc.want[k]) < 0.1
+		}
+		if !ok {
+			t.Errorf("from %v to %v got %v, expected %v", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestRouteSubPath(t *testing.T) {
+	pts := db.routes[0].pts
+	buf, err := db.RouteSubPath(0, pts[10].Lat, pts[10].Lon, pts[20].Lat, pts[20].Lon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := route.GetRootAsRoute(buf, 0)
+	if string(r.Name()) != "149" || r.PathLength() != 11 {
+		t.Errorf("got route %v with %v points, expected 149 with 11", string(r.Name()), r.PathLength())
+	}
+	var pt route.GeoPoint
+	r.Path(&pt, 0)
+	if pt.Lat() != micro(pts[10].Lat) || pt.Lon() != micro(pts[10].Lon) {
+		t.Errorf("path starts at %v, %v, expected %v", pt.Lat(), pt.Lon(), pts[10])
+	}
+
+	if _, err := db.RouteSubPath(1, 40.5, 72.8, 40.5, 72.8); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if _, err := db.RouteSubPath(0, 40.5, 72.8, 40.5, 272.8); err == nil {
+		t.Error("expected error for invalid longitude")
+	}
+}
+
+func TestDistanceBetween(t *testing.T) {
+	// Up one side of a U and down the other.
+	sdb := makeDb(t, testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.51, 72.80, 40.51, 72.801, 40.50, 72.801}})
+	up := distance(Stop{40.50, 72.80}, Stop{40.51, 72.80})
+	across := distance(Stop{40.51, 72.80}, Stop{40.51, 72.801})
+
+	d, err := sdb.DistanceBetween(0, 40.50, 72.80, 40.50, 72.801)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := 2*up + across; math.Abs(d-exp) > 0.5 {
+		t.Errorf("distance along route %v, expected %v", d, exp)
+	}
+	if straight := distance(Stop{40.50, 72.80}, Stop{40.50, 72.801}); d < 10*straight {
+		t.Errorf("distance along route %v not much longer than straight %v", d, straight)
+	}
+	if back, _ := sdb.DistanceBetween(0, 40.50, 72.801, 40.50, 72.80); math.Abs(back-d) > 1e-6 {
+		t.Errorf("distance back %v, expected %v", back, d)
+	}
+	if d, _ := sdb.DistanceBetween(0, 40.505, 72.7999, 40.505, 72.7999); d != 0 {
+		t.Errorf("distance to the same place %v", d)
+	}
+
+	if _, err := sdb.DistanceBetween(1, 40.5, 72.8, 40.5, 72.8); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+	if _, err := sdb.DistanceBetween(0, 91, 72.8, 40.5, 72.8); err == nil {
+		t.Error("expected error for invalid latitude")
+	}
+}
+
+func TestDistanceBetweenCircular(t *testing.T) {
+	sdb := makeDb(t, testRoute{"kg-osh-1", []float64{40.50, 72.80, 40.51, 72.80, 40.51, 72.81, 40.5001, 72.81, 40.5001, 72.80}})
+	length, _ := sdb.RouteLength(0)
+	gap := distance(Stop{40.5001, 72.80}, Stop{40.50, 72.80})
+	up := distance(Stop{40.50, 72.80}, Stop{40.51, 72.80})
+
+	// Forwards, as on any route.
+	d, err := sdb.DistanceBetween(0, 40.505, 72.80, 40.51, 72.80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(d-up/2) > 0.5 {
+		t.Errorf("distance forwards %v, expected %v", d, up/2)
+	}
+
+	// From the top corner, round the loop and up again.
+	d, err = sdb.DistanceBetween(0, 40.51, 72.80, 40.505, 72.80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := length - up + gap + up/2; math.Abs(d-exp) > 0.5 {
+		t.Errorf("distance round the loop %v, expected %v", d, exp)
+	}
+}
@@ -0,0 +1,83 @@
+package routedb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// A routeSummary is the summary of one route in SummaryJSON.
+type routeSummary struct {
+	Index        int     `json:"index"`
+	Country      string  `json:"country"`
+	City         string  `json:"city"`
+	Name         string  `json:"name"`
+	Points       int     `json:"points"`
+	LengthMeters float64 `json:"length_meters"`
+}
+
+// SummaryJSON returns a summary of the database as a JSON object,
+// with the number of routes, the bounds, and for each route its
+// index, country, city, name in the locale set by SetLocale, number
+// of waypoints and length:
+//
+//	{
+//	  "route_count": 1,
+//	  "bounds": {"n": 40.54, "e": 72.82, "s": 40.50, "w": 72.79},
+//	  "routes": [
+//	    {"index": 0, "country": "kg", "city": "osh", "name": "149",
+//	     "points": 477, "length_meters": 6630.5}
+//	  ]
+//	}
+func (db *Db) SummaryJSON() ([]byte, error) {
+	var s struct {
+		RouteCount int `json:"route_count"`
+		Bounds     struct {
+			N float64 `json:"n"`
+			E float64 `json:"e"`
+			S float64 `json:"s"`
+			W float64 `json:"w"`
+		} `json:"bounds"`
+		Routes []routeSummary `json:"routes"`
+	}
+	s.RouteCount = len(db.routes)
+	s.Bounds.N, s.Bounds.E = db.bounds.N, db.bounds.E
+	s.Bounds.S, s.Bounds.W = db.bounds.S, db.bounds.W
+	s.Routes = make([]routeSummary, len(db.routes))
+	for i, t := range db.routes {
+		country, city, _ := t.split()
+		s.Routes[i] = routeSummary{i, country, city, t.name(db.locale), len(t.pts), t.length}
+	}
+	return json.Marshal(s)
+}
+
+// routeStringEnds is how many coordinates RouteString shows at each
+// end of a route.
+const routeStringEnds = 3
+
+// RouteString returns a human readable description of route i, for
+// debugging: its key, number of waypoints, length, bounds, and its
+// first and last few coordinates.
+func (db *Db) RouteString(i int) (string, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return "", err
+	}
+	country, city, name := t.split()
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "route %v: %v (country %v, city %v, name %v)\n", i, t.id, country, city, name)
+	fmt.Fprintf(&buf, "  points: %v\n", len(t.pts))
+	fmt.Fprintf(&buf, "  length: %.0f m\n", t.length)
+	b := t.bounds
+	fmt.Fprintf(&buf, "  bounds: N %.6f E %.6f S %.6f W %.6f\n", b.N, b.E, b.S, b.W)
+	for j, pt := range t.pts {
+		if j == routeStringEnds && len(t.pts) > 2*routeStringEnds {
+			fmt.Fprintf(&buf, "  ... %v more\n", len(t.pts)-2*routeStringEnds)
+		}
+		if j >= routeStringEnds && j < len(t.pts)-routeStringEnds {
+			continue
+		}
+		fmt.Fprintf(&buf, "  %4d: %.6f, %.6f\n", j, pt.Lat, pt.Lon)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,55 @@
+package routedb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSummaryJSON(t *testing.T) {
+	for _, db := range []*Db{db, &Db{}} {
+		buf, err := db.SummaryJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var s struct {
+			RouteCount int `json:"route_count"`
+			Bounds     map[string]float64
+			Routes     []map[string]interface{}
+		}
+		if err := json.Unmarshal(buf, &s); err != nil {
+			t.Fatalf("%s: %v", buf, err)
+		}
+		if s.RouteCount != db.Routes() || len(s.Routes) != db.Routes() {
+			t.Errorf("route count is %v with %v routes, expected %v", s.RouteCount, len(s.Routes), db.Routes())
+		}
+		if s.Bounds["n"] != db.Bounds().N || s.Bounds["w"] != db.Bounds().W {
+			t.Errorf("bounds are %v, expected %v", s.Bounds, *db.Bounds())
+		}
+	}
+
+	buf, _ := db.SummaryJSON()
+	var s struct {
+		Routes []routeSummary
+	}
+	json.Unmarshal(buf, &s)
+	l, _ := db.RouteLength(0)
+	if s.Routes[0] != (routeSummary{0, "kg", "osh", "149", 477, l}) {
+		t.Errorf("route summary is %+v", s.Routes[0])
+	}
+}
+
+func TestRouteString(t *testing.T) {
+	s, err := db.RouteString(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"country kg", "points: 477", "0: 40.501050, 72.822550", "... 471 more"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("%q missing from:\n%v", want, s)
+		}
+	}
+	if _, err := db.RouteString(-1); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package routedb
+
+import "strings"
+
+// parseTags splits the comma separated keywords of a GPX file into
+// tags, trimming the space around them.
+func parseTags(keywords string) []string {
+	tags := []string{}
+	for _, tag := range strings.Split(keywords, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// RouteTags returns the tags of route i, such as "express" or
+// "night", taken from the keywords in the metadata of its GPX file.
+// The tags "outbound" and "inbound" give the direction the route
+// travels in; see RouteDirections.
+func (db *Db) RouteTags(i int) ([]string, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, t.tags...), nil
+}
+
+// RoutesWithTag returns the indices of the routes tagged with tag,
+// ignoring case.
+func (db *Db) RoutesWithTag(tag string) []int {
+	routes := []int{}
+	for i, t := range db.routes {
+		for _, tt := range t.tags {
+			if strings.EqualFold(tt, tag) {
+				routes = append(routes, i)
+				break
+			}
+		}
+	}
+	return routes
+}
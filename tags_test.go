@@ -0,0 +1,38 @@
+package routedb
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRouteTags(t *testing.T) {
+	db := loadTestdata(t, "testdata/tags.zip")
+	for i, exp := range []string{"[express night]", "[night]", "[]"} {
+		tags, err := db.RouteTags(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tags == nil || fmt.Sprint(tags) != exp {
+			t.Errorf("route %v has tags %v, expected %v", i, tags, exp)
+		}
+	}
+	if _, err := db.RouteTags(3); err == nil {
+		t.Error("expected out of range error")
+	}
+}
+
+func TestRoutesWithTag(t *testing.T) {
+	db := loadTestdata(t, "testdata/tags.zip")
+	for _, tc := range []struct {
+		tag string
+		exp string
+	}{
+		{"night", "[0 1]"},
+		{"Express", "[0]"},
+		{"airport", "[]"},
+	} {
+		if got := fmt.Sprint(db.RoutesWithTag(tc.tag)); got != tc.exp {
+			t.Errorf("routes tagged %v are %v, expected %v", tc.tag, got, tc.exp)
+		}
+	}
+}
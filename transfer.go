@@ -0,0 +1,299 @@
+package routedb
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/google/flatbuffers/go"
+	"github.com/jeffallen/routedb/route"
+)
+
+// transfersFile is the name of the file in a routedb zip caching the
+// transfer table, as written by ArchiveWithTransfers.
+const transfersFile = "transfers.bin"
+
+// A transfer is a place to change between routes from and to, with
+// from < to: a boarding point a of route from within walking distance
+// d meters of a boarding point b of route to.
+type transfer struct {
+	from, to int
+	a, b     Stop
+	d        float64
+}
+
+// byRoutePair sorts transfers by their routes.
+type byRoutePair []transfer
+
+func (s byRoutePair) Len() int { return len(s) }
+func (s byRoutePair) Less(i, j int) bool {
+	if s[i].from != s[j].from {
+		return s[i].from < s[j].from
+	}
+	return s[i].to < s[j].to
+}
+func (s byRoutePair) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+
+// boardingWithin returns the boarding points, as given by
+// boardingPoints, within meters of p, in no particular order. The
+// point index of each hit is its index in boardingPoints.
+func (db *Db) boardingWithin(p Stop, meters float64) []hit {
+	if db.stopIndex != nil {
+		return db.stopIndex.within(p, meters)
+	}
+	if !db.hasNamedStops() {
+		return db.withinAll(p, meters)
+	}
+	var hits []hit
+	for i, t := range db.routes {
+		for j, pt := range boardingPoints(t) {
+			if d := distance(p, pt); d <= meters {
+				hits = append(hits, hit{pt, i, j, d})
+			}
+		}
+	}
+	return hits
+}
+
+// transfers returns, for each pair of routes with boarding points
+// within walkMeters of each other, the closest such pair of points,
+// ordered by route.
+func (db *Db) transfers(walkMeters float64) []transfer {
+	best := make(map[[2]int]transfer)
+	for i, t := range db.routes {
+		for _, p := range boardingPoints(t) {
+			for _, h := range db.boardingWithin(p, walkMeters) {
+				if h.ri <= i {
+					continue
+				}
+				key := [2]int{i, h.ri}
+				if o, ok := best[key]; !ok || h.d < o.d {
+					best[key] = transfer{i, h.ri, p, h.pt, h.d}
+				}
+			}
+		}
+	}
+	out := make([]transfer, 0, len(best))
+	for _, tr := range best {
+		out = append(out, tr)
+	}
+	sort.Sort(byRoutePair(out))
+	return out
+}
+
+// Transfers returns the transfer table of the database as a FlatBuffer
+// holding a TransferTable: every pair of routes with boarding points
+// within walkMeters of each other, where riders can change between
+// them. Each pair is listed once, with the lower route index first in
+// FromRoute, along with the closest pair of boarding points, in From
+// and To, and the distance between them in meters. The boarding points
+// of a route are its named stops if it has any, and otherwise its
+// waypoints.
+//
+// Finding the transfers is costly for a large database, so they can be
+// computed ahead of time and saved in the routedb with
+// ArchiveWithTransfers. The saved table is returned when it was made
+// for the same walkMeters and the same boarding points, as told by its
+// Checksum, which is a hash of the boarding points of every route.
+func (db *Db) Transfers(walkMeters float64) ([]byte, error) {
+	if !(walkMeters >= 0) || math.IsInf(walkMeters, 1) {
+		return nil, errors.New("walking distance must be a non-negative number")
+	}
+	sum := db.transfersChecksum()
+	if db.savedTransfers != nil {
+		tt := route.GetRootAsTransferTable(db.savedTransfers, 0)
+		if tt.Checksum() == sum && tt.WalkMeters() == float32(walkMeters) {
+			return append([]byte{}, db.savedTransfers...), nil
+		}
+	}
+	return finishTransfers(sum, walkMeters, db.transfers(walkMeters)), nil
+}
+
+// transfersChecksum returns a hash of what the transfers depend on:
+// the boarding points of every route, in order.
+func (db *Db) transfersChecksum() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, t := range db.routes {
+		pts := boardingPoints(t)
+		binary.LittleEndian.PutUint32(buf[:4], uint32(len(pts)))
+		h.Write(buf[:4])
+		for _, pt := range pts {
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(pt.Lat))
+			h.Write(buf[:])
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(pt.Lon))
+			h.Write(buf[:])
+		}
+	}
+	return h.Sum64()
+}
+
+// transferVectors gives the size of an element of each of the vectors
+// of a TransferTable, from from_route to meters.
+var transferVectors = []uint64{4, 4, 8, 8, 4}
+
+// checkTransferTable reports whether buf holds a TransferTable which
+// can be read without going out of its bounds: every offset in it
+// points inside it, and its vectors have the same length.
+func checkTransferTable(buf []byte) bool {
+	n := uint64(len(buf))
+	u32 := func(at uint64) uint64 { return uint64(flatbuffers.GetUint32(buf[at:])) }
+	u16 := func(at uint64) uint64 { return uint64(flatbuffers.GetUint16(buf[at:])) }
+	if n < 4 {
+		return false
+	}
+	table := u32(0)
+	if table+4 > n {
+		return false
+	}
+	vtable := int64(table) - int64(flatbuffers.GetInt32(buf[table:]))
+	if vtable < 0 || uint64(vtable)+4 > n {
+		return false
+	}
+	vt := uint64(vtable)
+	vtLen, tableLen := u16(vt), u16(vt+2)
+	if vtLen < 4 || vt+vtLen > n || table+tableLen > n {
+		return false
+	}
+	// field returns the offset in the table of field slot, or 0
+	// if it is absent, checking that size bytes of it fit.
+	field := func(slot int, size uint64) (uint64, bool) {
+		at := 4 + 2*uint64(slot)
+		if at+2 > vtLen {
+			return 0, true
+		}
+		o := u16(vt + at)
+		return o, o == 0 || o+size <= tableLen
+	}
+	if _, ok := field(0, 8); !ok {
+		return false
+	}
+	if _, ok := field(1, 4); !ok {
+		return false
+	}
+	count := int64(-1)
+	for k, size := range transferVectors {
+		o, ok := field(2+k, 4)
+		if !ok {
+			return false
+		}
+		l := uint64(0)
+		if o != 0 {
+			v := table + o
+			v += u32(v)
+			if v+4 > n {
+				return false
+			}
+			l = u32(v)
+			if v+4+l*size > n {
+				return false
+			}
+		}
+		if count >= 0 && int64(l) != count {
+			return false
+		}
+		count = int64(l)
+	}
+	return true
+}
+
+// finishTransfers returns a FlatBuffer holding a TransferTable of trs,
+// for a database with the given transfersChecksum.
+func finishTransfers(checksum uint64, walkMeters float64, trs []transfer) []byte {
+	b := flatbuffers.NewBuilder(0)
+
+	route.TransferTableStartMetersVector(b, len(trs))
+	for k := len(trs) - 1; k >= 0; k-- {
+		b.PrependFloat32(float32(trs[k].d))
+	}
+	meters := b.EndVector(len(trs))
+	route.TransferTableStartToVector(b, len(trs))
+	for k := len(trs) - 1; k >= 0; k-- {
+		route.CreateGeoPoint(b, micro(trs[k].b.Lat), micro(trs[k].b.Lon))
+	}
+	to := b.EndVector(len(trs))
+	route.TransferTableStartFromVector(b, len(trs))
+	for k := len(trs) - 1; k >= 0; k-- {
+		route.CreateGeoPoint(b, micro(trs[k].a.Lat), micro(trs[k].a.Lon))
+	}
+	from := b.EndVector(len(trs))
+	route.TransferTableStartToRouteVector(b, len(trs))
+	for k := len(trs) - 1; k >= 0; k-- {
+		b.PrependInt32(int32(trs[k].to))
+	}
+	toRoute := b.EndVector(len(trs))
+	route.TransferTableStartFromRouteVector(b, len(trs))
+	for k := len(trs) - 1; k >= 0; k-- {
+		b.PrependInt32(int32(trs[k].from))
+	}
+	fromRoute := b.EndVector(len(trs))
+
+	route.TransferTableStart(b)
+	route.TransferTableAddChecksum(b, checksum)
+	route.TransferTableAddWalkMeters(b, float32(walkMeters))
+	route.TransferTableAddFromRoute(b, fromRoute)
+	route.TransferTableAddToRoute(b, toRoute)
+	route.TransferTableAddFrom(b, from)
+	route.TransferTableAddTo(b, to)
+	route.TransferTableAddMeters(b, meters)
+	b.Finish(route.TransferTableEnd(b))
+	return b.Bytes[b.Head():]
+}
+
+// ArchiveWithTransfers returns the zip the database was loaded from,
+// with the transfer table for walkMeters, as returned by Transfers,
+// saved in it, replacing any saved before. Loading the result gives a
+// database which returns the saved table from Transfers instead of
+// computing it again. The routes in the zip are those loaded, so the
+// table is only used if the routes have not changed since.
+func (db *Db) ArchiveWithTransfers(walkMeters float64) ([]byte, error) {
+	if db.zip == nil {
+		return nil, errors.New("database was not loaded from a zip")
+	}
+	tt, err := db.Transfers(walkMeters)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, zf := range db.zip.File {
+		if zf.Name == transfersFile {
+			continue
+		}
+		if err := copyZipFile(zw, zf); err != nil {
+			return nil, err
+		}
+	}
+	w, err := zw.Create(transfersFile)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(tt); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// copyZipFile copies zf into zw.
+func copyZipFile(zw *zip.Writer, zf *zip.File) error {
+	r, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	h := zf.FileHeader
+	w, err := zw.CreateHeader(&h)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
@@ -0,0 +1,180 @@
+package routedb
+
+import (
+	"archive/zip"
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestTransfers(t *testing.T) {
+	sdb := makeDb(t,
+		testRoute{"kg-osh-1", []float64{40.5, 72.80, 40.5, 72.81}},
+		// Crosses route 0, with a waypoint 50 m from its end.
+		testRoute{"kg-osh-2", []float64{40.49, 72.8106, 40.5, 72.8106, 40.51, 72.8106}},
+		testRoute{"kg-osh-3", []float64{40.6, 72.9}},
+		// Joins route 1 at its end.
+		testRoute{"kg-osh-4", []float64{40.51, 72.8106, 40.52, 72.8106}},
+	)
+	for _, opts := range []struct {
+		noIndex bool
+	}{{false}, {true}} {
+		if opts.noIndex {
+			sdb.index = nil
+		}
+		buf, err := sdb.Transfers(100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt := route.GetRootAsTransferTable(buf, 0)
+		if tt.Checksum() != sdb.transfersChecksum() || tt.WalkMeters() != 100 {
+			t.Errorf("checksum %v, walk %v", tt.Checksum(), tt.WalkMeters())
+		}
+		if tt.FromRouteLength() != 2 || tt.ToRouteLength() != 2 || tt.FromLength() != 2 || tt.ToLength() != 2 || tt.MetersLength() != 2 {
+			t.Fatalf("%v transfers, expected 2", tt.FromRouteLength())
+		}
+		for k, want := range []struct {
+			from, to int
+			a, b     Stop
+		}{
+			{0, 1, Stop{40.5, 72.81}, Stop{40.5, 72.8106}},
+			{1, 3, Stop{40.51, 72.8106}, Stop{40.51, 72.8106}},
+		} {
+			var a, b route.GeoPoint
+			tt.From(&a, k)
+			tt.To(&b, k)
+			if int(tt.FromRoute(k)) != want.from || int(tt.ToRoute(k)) != want.to ||
+				a.Lat() != micro(want.a.Lat) || a.Lon() != micro(want.a.Lon) ||
+				b.Lat() != micro(want.b.Lat) || b.Lon() != micro(want.b.Lon) {
+				t.Errorf("noIndex %v: transfer %v is %v to %v at %v, %v to %v, %v", opts.noIndex, k,
+					tt.FromRoute(k), tt.ToRoute(k), a.Lat(), a.Lon(), b.Lat(), b.Lon())
+			}
+			if d := distance(want.a, want.b); math.Abs(float64(tt.Meters(k))-d) > 0.01 {
+				t.Errorf("transfer %v is %v m, expected %v", k, tt.Meters(k), d)
+			}
+		}
+	}
+
+	if _, err := sdb.Transfers(-1); err == nil {
+		t.Error("expected error for negative distance")
+	}
+}
+
+func TestArchiveWithTransfers(t *testing.T) {
+	in := zipGPX(t, stopsGPX...)
+	sdb, err := Load(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := sdb.ArchiveWithTransfers(15000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ldb, err := Load(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ldb.Routes() != sdb.Routes() || ldb.Checksum() != sdb.Checksum() {
+		t.Fatalf("archive has %v routes, expected %v", ldb.Routes(), sdb.Routes())
+	}
+	want, _ := sdb.Transfers(15000)
+	if ldb.savedTransfers == nil {
+		t.Fatal("transfers not saved")
+	}
+	if got, _ := ldb.Transfers(15000); !bytes.Equal(got, want) || !bytes.Equal(ldb.savedTransfers, want) {
+		t.Error("saved transfers differ")
+	}
+	if tt := route.GetRootAsTransferTable(want, 0); tt.FromRouteLength() != 1 {
+		t.Errorf("%v transfers, expected 1", tt.FromRouteLength())
+	}
+
+	// The saved table is only used for the same distance and routes.
+	if got, _ := ldb.Transfers(100); route.GetRootAsTransferTable(got, 0).FromRouteLength() != 0 {
+		t.Error("saved table used for another distance")
+	}
+	ldb.removeRoute(1)
+	ldb.RecomputeBounds()
+	if got, _ := ldb.Transfers(15000); route.GetRootAsTransferTable(got, 0).FromRouteLength() != 0 {
+		t.Error("saved table used for changed routes")
+	}
+
+	// Nor is it used when the named stops have moved, though the
+	// paths have not.
+	mdb, err := Load(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mdb.routes[0].stops[1].pt = Stop{40.6, 72.9}
+	if got, _ := mdb.Transfers(15000); bytes.Equal(got, want) {
+		t.Error("saved table used for moved stops")
+	}
+
+	// Saving again replaces the table.
+	rdb, err := Load(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := rdb.ArchiveWithTransfers(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(again), int64(len(again)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 3 || zr.File[2].Name != transfersFile {
+		t.Errorf("archive holds %v files", len(zr.File))
+	}
+	adb, err := Load(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tt := route.GetRootAsTransferTable(adb.savedTransfers, 0); tt.WalkMeters() != 100 {
+		t.Errorf("saved table is for %v m", tt.WalkMeters())
+	}
+
+	b := NewBuilder()
+	b.AddRoute("kg", "osh", "1", []float64{40.5}, []float64{72.8})
+	bdb, _ := b.Build()
+	if _, err := bdb.ArchiveWithTransfers(100); err == nil {
+		t.Error("expected error for database not loaded from a zip")
+	}
+}
+
+func TestSavedTransfersInvalid(t *testing.T) {
+	sdb, err := Load(zipGPX(t, stopsGPX...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, err := sdb.Transfers(15000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !checkTransferTable(valid) {
+		t.Fatal("valid table rejected")
+	}
+	for n := 0; n < len(valid); n++ {
+		if checkTransferTable(valid[:n]) {
+			t.Errorf("table truncated to %v bytes accepted", n)
+		}
+	}
+
+	// An invalid table is dropped with a warning, and computed
+	// again.
+	for _, saved := range []string{"", "xx", string(valid[:len(valid)/2])} {
+		files := append([]string{}, "0.xml", stopsGPX[0], "1.xml", stopsGPX[1], transfersFile, saved)
+		ldb, err := Load(zipFiles(t, files...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ldb.savedTransfers != nil || !strings.Contains(ldb.Warnings(), transfersFile) {
+			t.Errorf("%v byte table kept, warnings %q", len(saved), ldb.Warnings())
+		}
+		if got, err := ldb.Transfers(15000); err != nil || !bytes.Equal(got, valid) {
+			t.Errorf("%v byte table: transfers differ, %v", len(saved), err)
+		}
+	}
+}
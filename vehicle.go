@@ -0,0 +1,41 @@
+package routedb
+
+import "strings"
+
+// normalizeVehicle returns the vehicle type v, as given in a GPX
+// file, in the form returned by RouteVehicle.
+func normalizeVehicle(v string) string {
+	return strings.ToLower(strings.TrimSpace(v))
+}
+
+// RouteVehicle returns the type of vehicle serving route i, such as
+// "bus", "trolleybus", "tram" or "marshrutka", or the empty string if
+// it is not known. It is taken from the <type> of the track in the
+// route's GPX file, lower cased, and is also in the Route FlatBuffer,
+// so that maps can show a different icon for each.
+func (db *Db) RouteVehicle(i int) (string, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return "", err
+	}
+	return t.vehicle, nil
+}
+
+// RoutesByVehicle returns the indices of the routes served by any of
+// the comma separated vehicle types in vehicles, ignoring case, such
+// as "bus,trolleybus" for riders who would rather not take a
+// marshrutka. Listing "" as a type, as in "bus,", includes the routes
+// whose vehicle type is not known.
+func (db *Db) RoutesByVehicle(vehicles string) []int {
+	want := make(map[string]bool)
+	for _, v := range strings.Split(vehicles, ",") {
+		want[normalizeVehicle(v)] = true
+	}
+	routes := []int{}
+	for i, t := range db.routes {
+		if want[t.vehicle] {
+			routes = append(routes, i)
+		}
+	}
+	return routes
+}
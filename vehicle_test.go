@@ -0,0 +1,50 @@
+package routedb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jeffallen/routedb/route"
+)
+
+func TestRouteVehicle(t *testing.T) {
+	var files []string
+	for _, v := range []string{"Bus", "marshrutka", "", " tram "} {
+		files = append(files, fmt.Sprintf(`<gpx><metadata><name>kg-osh-1</name></metadata>
+<trk><type>%v</type><trkseg><trkpt lat="40.5" lon="72.8"/></trkseg></trk></gpx>`, v))
+	}
+	sdb, err := Load(zipGPX(t, files...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"bus", "marshrutka", "", "tram"} {
+		v, err := sdb.RouteVehicle(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != want {
+			t.Errorf("route %v vehicle is %q, expected %q", i, v, want)
+		}
+		buf, _ := sdb.Route(i)
+		if v := string(route.GetRootAsRoute(buf, 0).Vehicle()); v != want {
+			t.Errorf("route %v FlatBuffer vehicle is %q, expected %q", i, v, want)
+		}
+	}
+	if _, err := sdb.RouteVehicle(4); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		vehicles string
+		want     []int
+	}{
+		{"bus", []int{0}},
+		{"BUS, Tram", []int{0, 3}},
+		{"bus,trolleybus,tram,", []int{0, 2, 3}},
+		{"ferry", []int{}},
+	} {
+		if got := sdb.RoutesByVehicle(tc.vehicles); fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("routes by %q are %v, expected %v", tc.vehicles, got, tc.want)
+		}
+	}
+}
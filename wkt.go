@@ -0,0 +1,35 @@
+package routedb
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// RouteWKT returns the path of route i as a Well-Known Text
+// LineString, for importing into GIS tools such as PostGIS. As WKT
+// requires, each coordinate is written longitude first:
+//
+//	LINESTRING (72.82255 40.50105, 72.822266 40.501038, ...)
+//
+// A route with no waypoints is LINESTRING EMPTY.
+func (db *Db) RouteWKT(i int) (string, error) {
+	t, err := db.routeAt(i)
+	if err != nil {
+		return "", err
+	}
+	if len(t.pts) == 0 {
+		return "LINESTRING EMPTY", nil
+	}
+	var buf bytes.Buffer
+	buf.WriteString("LINESTRING (")
+	for j, pt := range t.pts {
+		if j > 0 {
+			buf.WriteString(", ")
+		}
+		buf.WriteString(strconv.FormatFloat(pt.Lon, 'f', -1, 64))
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatFloat(pt.Lat, 'f', -1, 64))
+	}
+	buf.WriteByte(')')
+	return buf.String(), nil
+}
@@ -0,0 +1,33 @@
+package routedb
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRouteWKT(t *testing.T) {
+	s, err := db.RouteWKT(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "LINESTRING (72.82255 40.50105, ") {
+		t.Errorf("wrong start, lon first expected: %.50v", s)
+	}
+	if !strings.HasSuffix(s, ", 72.79698 40.53928)") {
+		t.Errorf("wrong end: %v", s[len(s)-50:])
+	}
+	if n := strings.Count(s, ",") + 1; n != 477 {
+		t.Errorf("%v coordinates, expected 477", n)
+	}
+
+	edb, err := LoadWithOptions(mustRead(t, "testdata/empty.zip"), &LoadOptions{KeepEmptyRoutes: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, err := edb.RouteWKT(1); err != nil || s != "LINESTRING EMPTY" {
+		t.Errorf("empty route is %q, %v", s, err)
+	}
+	if _, err := db.RouteWKT(1); !IsOutOfRange(err) {
+		t.Errorf("expected out of range, got %v", err)
+	}
+}